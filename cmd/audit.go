@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/codeupify/upify/internal/sbom"
+	"github.com/spf13/cobra"
+)
+
+var auditDatabase string
+var auditSeverity string
+
+var auditCmd = &cobra.Command{
+	Use:   "audit [platform]",
+	Short: "Check the packages shipped to a platform against a local advisory database",
+	Long: `Stage the application for a platform and match the exact versions of the
+installed packages against an OSV advisory dump on disk. Nothing is fetched,
+so the database has to be refreshed separately.
+Currently supported platforms: {PLATFORMS}
+
+Example:
+  upify audit aws --database osv/
+  upify audit gcp --severity critical`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		cfg = infra.ResolveHandler(cfg)
+		return auditPlatform(platform.Platform(args[0]), cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.Long = strings.Replace(auditCmd.Long, "{PLATFORMS}", strings.Join(platform.Names(), ", "), 1)
+	auditCmd.Flags().StringVar(&auditDatabase, "database", "", "Path to the OSV database, a directory of JSON records or a zip (default audit.database)")
+	auditCmd.Flags().StringVar(&auditSeverity, "severity", "", "Fail on vulnerabilities at or above this severity: low, moderate, high or critical (default high)")
+}
+
+func auditPlatform(p platform.Platform, cfg *config.Config) error {
+	tempDir, err := os.MkdirTemp("", "upify_audit_")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	stagingDir := filepath.Join(tempDir, "source")
+	if err := stage(p, cfg, stagingDir); err != nil {
+		return err
+	}
+
+	if err := fs.ResolveSymlinks(stagingDir); err != nil {
+		return fmt.Errorf("failed to resolve symlinks: %v", err)
+	}
+
+	components, err := sbom.Scan(stagingDir)
+	if err != nil {
+		return fmt.Errorf("failed to scan packages: %v", err)
+	}
+
+	return infra.AuditComponents(cfg, components, auditDatabase, auditSeverity)
+}
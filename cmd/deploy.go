@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
 	"github.com/codeupify/upify/internal/platform"
-	"github.com/codeupify/upify/internal/platform/aws"
-	"github.com/codeupify/upify/internal/platform/gcp"
 	"github.com/spf13/cobra"
 )
 
@@ -14,10 +16,14 @@ var deployCmd = &cobra.Command{
 	Use:   "deploy [platform]",
 	Short: "Deploy the application to a specified platform",
 	Long: `Deploy the application to a specified platform.
-Currently supported platforms: aws, gcp
+Currently supported platforms: {PLATFORMS}
+
+Pass --artifact to deploy a zip built by ` + "`upify package`" + ` instead of
+building a new one.
 
 Example:
-  upify deploy aws`,
+  upify deploy aws
+  upify deploy aws --artifact dist/app.zip`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		platform := args[0]
@@ -26,28 +32,52 @@ Example:
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		return deploy(platform, cfg)
+		cfg = infra.ResolveHandler(cfg)
+		return deploy(platform, cfg, deployArtifact)
 	},
 }
 
+var deployArtifact string
+var deployNoVerify bool
+
 func init() {
 	rootCmd.AddCommand(deployCmd)
+	deployCmd.Long = strings.Replace(deployCmd.Long, "{PLATFORMS}", strings.Join(platform.Names(), ", "), 1)
+	deployCmd.Flags().StringVar(&deployArtifact, "artifact", "", "Deploy a prebuilt zip built by upify package instead of building one")
+	deployCmd.Flags().BoolVar(&deployNoVerify, "no-verify", false, "Deploy --artifact without checking it against its manifest")
 }
 
-func deploy(platformStr string, cfg *config.Config) error {
-	switch platformStr {
-	case string(platform.AWS):
-		fmt.Println("Deploying to AWS...")
-		if err := aws.Deploy(cfg); err != nil {
-			return fmt.Errorf("failed to deploy to AWS: %w", err)
+func deploy(platformStr string, cfg *config.Config, artifactPath string) error {
+	provider, err := platform.Get(platform.Platform(platformStr))
+	if err != nil {
+		return err
+	}
+
+	if artifactPath != "" {
+		if deployNoVerify {
+			if _, err := os.Stat(artifactPath); err != nil {
+				return fmt.Errorf("artifact not found: %s", artifactPath)
+			}
+			fmt.Printf("Skipping verification of %s\n", artifactPath)
+		} else if err := infra.VerifyArtifact(provider.Name(), artifactPath); err != nil {
+			return err
 		}
-	case string(platform.GCP):
-		fmt.Println("Deploying to GCP...")
-		if err := gcp.Deploy(cfg); err != nil {
-			return fmt.Errorf("failed to deploy to GCP: %w", err)
+
+		absPath, err := filepath.Abs(artifactPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve artifact path: %w", err)
 		}
-	default:
-		return fmt.Errorf("unsupported platform: %s", platformStr)
+		artifactPath = absPath
+	}
+
+	fmt.Printf("Deploying to %s...\n", provider.Title())
+	if err := provider.Deploy(cfg, artifactPath); err != nil {
+		return fmt.Errorf("failed to deploy to %s: %w", provider.Title(), err)
+	}
+
+	// The deploy went through, so failing to read the outputs isn't fatal
+	if err := infra.PrintOutputs(provider.Name(), provider.Outputs()); err != nil {
+		fmt.Printf("Warning: %v\n", err)
 	}
 
 	return nil
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/cobra"
+)
+
+var destroyYes bool
+
+var destroyCmd = &cobra.Command{
+	Use:   "destroy [platform]",
+	Short: "Remove everything deployed to a platform",
+	Long: `Remove the resources a deploy created on a platform. The platform's
+configuration in .upify is kept, so it can be deployed again.
+Currently supported platforms: {PLATFORMS}
+
+Example:
+  upify destroy aws
+  upify destroy gcp-run --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		return destroy(platform.Platform(args[0]), cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(destroyCmd)
+	destroyCmd.Long = strings.Replace(destroyCmd.Long, "{PLATFORMS}", strings.Join(platform.Names(), ", "), 1)
+	destroyCmd.Flags().BoolVar(&destroyYes, "yes", false, "Don't ask for confirmation")
+}
+
+func destroy(p platform.Platform, cfg *config.Config) error {
+	provider, err := platform.Get(p)
+	if err != nil {
+		return err
+	}
+
+	if !destroyYes {
+		confirmed := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Remove everything %s deployed to %s?", cfg.Name, provider.Title()),
+		}
+		if err := survey.AskOne(prompt, &confirmed); err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	fmt.Printf("Destroying %s deployment...\n", provider.Title())
+	if err := provider.Destroy(cfg); err != nil {
+		return fmt.Errorf("failed to destroy %s deployment: %w", provider.Title(), err)
+	}
+
+	fmt.Printf("Destroyed %s deployment.\n", provider.Title())
+	return nil
+}
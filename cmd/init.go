@@ -142,7 +142,7 @@ var initCmd = &cobra.Command{
 		fmt.Println("Done!")
 
 		if entrypoint == "" {
-			mainPath := infra.GetMainPath(selectedLanguage)
+			mainPath := infra.GetMainPath(cfg)
 			fmt.Printf("\n\033[1mImportant!\033[0m To finish the configuration wrap your script by modifying the file at \033[1m%s\033[0m\n", mainPath)
 		}
 
@@ -161,8 +161,26 @@ func validateEntrypoint(path string) error {
 func determinePackageManager(language lang.Language) (lang.PackageManager, error) {
 	switch language {
 	case lang.Python:
+		if _, err := os.Stat("poetry.lock"); err == nil {
+			return lang.Poetry, nil
+		}
+		if _, err := os.Stat("Pipfile.lock"); err == nil {
+			return lang.Pipenv, nil
+		}
+		if _, err := os.Stat("uv.lock"); err == nil {
+			return lang.Uv, nil
+		}
 		return lang.Pip, nil
 	case lang.JavaScript, lang.TypeScript:
+		if _, err := os.Stat("pnpm-lock.yaml"); err == nil {
+			return lang.Pnpm, nil
+		}
+		if _, err := os.Stat("bun.lockb"); err == nil {
+			return lang.Bun, nil
+		}
+		if _, err := os.Stat("bun.lock"); err == nil {
+			return lang.Bun, nil
+		}
 		if _, err := os.Stat("yarn.lock"); err == nil {
 			return lang.Yarn, nil
 		}
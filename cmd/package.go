@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/cobra"
+)
+
+var packageOut string
+var packageAnalyze bool
+var packageAnalyzeLimit int
+var packagePush bool
+
+var packageCmd = &cobra.Command{
+	Use:   "package [platform]",
+	Short: "Build the deployment artifact for a platform without deploying",
+	Long: `Build the deployment artifact for a platform without deploying it and
+check it against the platform's size limits. The zip is written along with a
+manifest (hash, runtime, file list) that ` + "`upify deploy --artifact`" + ` verifies.
+With package_type: image, an OCI image tarball is written instead, and
+--push uploads it to image.repository.
+Currently supported platforms: {PLATFORMS}
+
+Example:
+  upify package aws --out dist/app.zip
+  upify package aws --analyze`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		cfg = infra.ResolveHandler(cfg)
+		return packageArtifact(platform.Platform(args[0]), cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packageCmd)
+	packageCmd.Long = strings.Replace(packageCmd.Long, "{PLATFORMS}", strings.Join(platform.Names(), ", "), 1)
+	packageCmd.Flags().StringVar(&packageOut, "out", "", "Path to write the artifact to (default dist/<name>-<platform>.zip)")
+	packageCmd.Flags().BoolVar(&packageAnalyze, "analyze", false, "Print the largest directories and packages in the artifact")
+	packageCmd.Flags().IntVar(&packageAnalyzeLimit, "top", 15, "Number of entries to show in the --analyze report")
+	packageCmd.Flags().BoolVar(&packagePush, "push", false, "Push the image to image.repository (package_type: image only)")
+}
+
+func packageArtifact(p platform.Platform, cfg *config.Config) error {
+	if err := infra.ValidatePlatformDir(p); err != nil {
+		return err
+	}
+
+	if err := infra.ValidatePackageType(cfg); err != nil {
+		return err
+	}
+
+	if packagePush && !cfg.ImagePackaging() {
+		return fmt.Errorf("--push requires package_type: image")
+	}
+
+	out := packageOut
+	if out == "" {
+		extension := "zip"
+		if cfg.ImagePackaging() {
+			extension = "tar"
+		}
+		out = filepath.Join("dist", fmt.Sprintf("%s-%s.%s", cfg.Name, p, extension))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "upify_package_")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	stagingDir := filepath.Join(tempDir, "source")
+	if err := stage(p, cfg, stagingDir); err != nil {
+		return err
+	}
+
+	if packageAnalyze {
+		if err := printSizeReport(stagingDir); err != nil {
+			return err
+		}
+	}
+
+	if cfg.ImagePackaging() {
+		if err := packageImage(p, cfg, stagingDir, filepath.Join(tempDir, "image"), out); err != nil {
+			return err
+		}
+	} else if err := infra.CreateArtifact(cfg, p, stagingDir, out); err != nil {
+		return err
+	}
+
+	manifest, err := infra.BuildManifest(cfg, p, stagingDir, out)
+	if err != nil {
+		return err
+	}
+	manifest.UpifyVersion = version
+
+	if err := infra.WriteManifest(manifest, infra.ManifestPath(out)); err != nil {
+		return fmt.Errorf("failed to write manifest: %v", err)
+	}
+
+	fmt.Printf("Packaged %s (sha256 %s)\n", out, manifest.SHA256)
+	return nil
+}
+
+func stage(p platform.Platform, cfg *config.Config, dir string) error {
+	provider, err := platform.Get(p)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Packaging for %s...\n", provider.Title())
+	return provider.Stage(cfg, dir)
+}
+
+func packageImage(p platform.Platform, cfg *config.Config, stagingDir string, workDir string, out string) error {
+	provider, err := platform.Get(p)
+	if err != nil {
+		return err
+	}
+
+	imageProvider, ok := provider.(platform.ImageProvider)
+	if !ok {
+		return fmt.Errorf("package_type image is not supported on %s", p)
+	}
+	rc := imageProvider.ImageConfig(cfg)
+
+	img, err := infra.CreateImage(cfg, p, stagingDir, rc, workDir, out)
+	if err != nil {
+		return err
+	}
+
+	if packagePush {
+		if _, err := infra.PushImage(cfg, img); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printSizeReport(dir string) error {
+	report, err := fs.AnalyzeDir(dir, packageAnalyzeLimit)
+	if err != nil {
+		return fmt.Errorf("failed to analyze staging directory: %v", err)
+	}
+
+	fmt.Printf("\nTotal unzipped size: %s\n", fs.FormatSize(report.Total))
+
+	fmt.Println("\nLargest directories:")
+	for _, entry := range report.Directories {
+		fmt.Printf("  %10s  %s\n", fs.FormatSize(entry.Size), entry.Name)
+	}
+
+	if len(report.Packages) > 0 {
+		fmt.Println("\nLargest packages:")
+		for _, entry := range report.Packages {
+			fmt.Printf("  %10s  %s\n", fs.FormatSize(entry.Size), entry.Name)
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
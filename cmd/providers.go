@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/codeupify/upify/internal/platform/plugin"
+
+	// Platforms register themselves with the platform package when
+	// imported. This is the only place a new platform has to be added to
+	_ "github.com/codeupify/upify/internal/platform/aws"
+	_ "github.com/codeupify/upify/internal/platform/awsecs"
+	_ "github.com/codeupify/upify/internal/platform/azure"
+	_ "github.com/codeupify/upify/internal/platform/cloudflare"
+	_ "github.com/codeupify/upify/internal/platform/gcp"
+	_ "github.com/codeupify/upify/internal/platform/gcprun"
+	_ "github.com/codeupify/upify/internal/platform/k8s"
+	_ "github.com/codeupify/upify/internal/platform/selfhost"
+)
+
+// Package variables are initialized before any init function in the
+// package, so plugins are registered after the built-in platforms, which
+// take precedence, and before the commands read the registry
+var _ = plugin.Discover()
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [platform]",
+	Short: "Switch a platform back to the release before the current one",
+	Long: `Switch a platform back to the release deployed before the current one.
+Currently supported platforms: {PLATFORMS}
+
+Example:
+  upify rollback selfhost`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		return rollback(platform.Platform(args[0]), cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	var names []string
+	for _, name := range platform.Names() {
+		provider, err := platform.Get(platform.Platform(name))
+		if err != nil {
+			continue
+		}
+		if _, ok := provider.(platform.RollbackProvider); ok {
+			names = append(names, name)
+		}
+	}
+	rollbackCmd.Long = strings.Replace(rollbackCmd.Long, "{PLATFORMS}", strings.Join(names, ", "), 1)
+}
+
+func rollback(p platform.Platform, cfg *config.Config) error {
+	provider, err := platform.Get(p)
+	if err != nil {
+		return err
+	}
+
+	rollbackProvider, ok := provider.(platform.RollbackProvider)
+	if !ok {
+		return fmt.Errorf("%s doesn't keep earlier releases to roll back to, deploy the previous version instead", provider.Title())
+	}
+
+	if err := infra.ValidatePlatformDir(p); err != nil {
+		return err
+	}
+
+	if err := rollbackProvider.Rollback(cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("Rolled back %s on %s\n", cfg.Name, provider.Title())
+	return nil
+}
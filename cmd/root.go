@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/codeupify/upify/internal/infra"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +15,7 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() error {
+	infra.UpifyVersion = version
 	rootCmd.SetVersionTemplate("Upify version: {{.Version}}\n") // Customize the version output if needed
 	return rootCmd.Execute()
 }
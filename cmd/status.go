@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [platform]",
+	Short: "Show the last deploy to a platform and where it's running",
+	Long: `Show the artifact last deployed to a platform and the platform's outputs,
+such as the URL of the app.
+Currently supported platforms: {PLATFORMS}
+
+Example:
+  upify status aws`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		return status(platform.Platform(args[0]), cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Long = strings.Replace(statusCmd.Long, "{PLATFORMS}", strings.Join(platform.Names(), ", "), 1)
+}
+
+func status(p platform.Platform, cfg *config.Config) error {
+	provider, err := platform.Get(p)
+	if err != nil {
+		return err
+	}
+
+	if err := infra.ValidatePlatformDir(p); err != nil {
+		return err
+	}
+
+	record, err := infra.ReadDeployRecord(p)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		fmt.Printf("%s hasn't been deployed to %s yet.\n", cfg.Name, provider.Title())
+		return nil
+	}
+
+	fmt.Printf("Last deployed to %s at %s (sha256 %s)\n", provider.Title(), record.DeployedAt.Local().Format("2006-01-02 15:04:05"), record.ArtifactSHA256)
+	if record.Image != "" {
+		fmt.Printf("image: %s\n", record.Image)
+	}
+
+	if statusProvider, ok := provider.(platform.StatusProvider); ok {
+		return statusProvider.Status(cfg)
+	}
+	return infra.PrintOutputs(p, provider.Outputs())
+}
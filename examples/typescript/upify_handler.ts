@@ -0,0 +1,25 @@
+// Report stack traces against the original .ts sources
+(process as any).setSourceMapsEnabled?.(true);
+
+type Handler = (...args: any[]) => unknown;
+
+const entrypoint = require('./upify_main');
+const app = entrypoint.default ?? entrypoint;
+
+export let handler: Handler | undefined = undefined;
+
+if (process.env.UPIFY_DEPLOY_PLATFORM === 'aws-lambda') {
+    const serverless = require('serverless-http');
+    let expressApp = app;
+    if (app && app['app']) {
+        expressApp = app['app'];
+    }
+    handler = serverless(expressApp);
+}
+
+if (process.env.UPIFY_DEPLOY_PLATFORM === 'gcp-cloudrun') {
+    const functions = require('@google-cloud/functions-framework');
+    functions.http('handler', (req: unknown, res: unknown) => {
+        app(req, res);
+    });
+}
@@ -1,15 +1,15 @@
-const express = require('express');
-const { getWeatherData } = require('./dist/index');
+import express, { Request, Response } from 'express';
+import { getWeatherData } from './index';
 
 const app = express();
 
-app.get('/', async (req, res) => {
+app.get('/', async (req: Request, res: Response) => {
     if (!req.query.city) {
         console.log("Specify a city in the query string");
         return res.status(400).json({ error: "Specify a city in the query string" });
     }
 
-    const city = req.query.city;
+    const city = String(req.query.city);
     console.log("Got a weather request for " + city);
     
     try {
@@ -17,8 +17,8 @@ app.get('/', async (req, res) => {
         res.status(200).json(responseData);
     } catch (error) {
         console.error("Error getting weather data:", error);
-        res.status(500).json({ error: error.message });
+        res.status(500).json({ error: (error as Error).message });
     }
 });
 
-module.exports = app;
\ No newline at end of file
+export = app;
@@ -0,0 +1,337 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/codeupify/upify/internal/sbom"
+)
+
+// Severity levels, from least to most severe
+const (
+	Unknown  = "UNKNOWN"
+	Low      = "LOW"
+	Moderate = "MODERATE"
+	High     = "HIGH"
+	Critical = "CRITICAL"
+)
+
+// exactVersion matches pinned versions, as opposed to ranges like ^1.2.0
+// declared in a package.json
+var exactVersion = regexp.MustCompile(`^v?[0-9][0-9A-Za-z.+_-]*$`)
+
+var severityRanks = map[string]int{
+	Unknown:  0,
+	Low:      1,
+	Moderate: 2,
+	"MEDIUM": 2,
+	High:     3,
+	Critical: 4,
+}
+
+// Finding is an installed package affected by an advisory
+type Finding struct {
+	Component sbom.Component
+	ID        string
+	Aliases   []string
+	Severity  string
+	FixedIn   string
+	Summary   string
+}
+
+// ParseSeverity validates a severity name, accepting MEDIUM for MODERATE
+func ParseSeverity(severity string) (string, error) {
+	severity = strings.ToUpper(strings.TrimSpace(severity))
+	if severity == "MEDIUM" {
+		return Moderate, nil
+	}
+	if _, ok := severityRanks[severity]; !ok || severity == Unknown {
+		return "", fmt.Errorf("unsupported severity: %s (use low, moderate, high or critical)", severity)
+	}
+	return severity, nil
+}
+
+// AtLeast reports whether a severity is at or above the threshold. Advisories
+// without a severity are treated as at least moderate
+func AtLeast(severity string, threshold string) bool {
+	rank := severityRanks[severity]
+	if severity == Unknown {
+		rank = severityRanks[Moderate]
+	}
+	return rank >= severityRanks[threshold]
+}
+
+// WantedPackages returns the keys LoadDatabase needs to keep advisories for
+func WantedPackages(components []sbom.Component) map[string]bool {
+	wanted := map[string]bool{}
+	for _, component := range components {
+		wanted[packageKey(component.Ecosystem, component.Name)] = true
+	}
+	return wanted
+}
+
+// Unpinned returns the components Audit skips because they have no exact
+// version, e.g. package.json ranges
+func Unpinned(components []sbom.Component) []sbom.Component {
+	var result []sbom.Component
+	for _, component := range components {
+		if !exactVersion.MatchString(component.Version) {
+			result = append(result, component)
+		}
+	}
+	return result
+}
+
+// Audit matches the exact version of every component against the database.
+// Components without a pinned version and advisories listed in ignore (by ID
+// or alias) are skipped
+func Audit(db *Database, components []sbom.Component, ignore []string) []Finding {
+	ignored := map[string]bool{}
+	for _, id := range ignore {
+		ignored[strings.ToUpper(id)] = true
+	}
+
+	var findings []Finding
+	for _, component := range components {
+		if !exactVersion.MatchString(component.Version) {
+			continue
+		}
+
+		key := packageKey(component.Ecosystem, component.Name)
+		for _, advisory := range db.advisories[key] {
+			if isIgnored(advisory, ignored) {
+				continue
+			}
+
+			affected, fixedIn, severity := matchAdvisory(advisory, key, component)
+			if !affected {
+				continue
+			}
+
+			findings = append(findings, Finding{
+				Component: component,
+				ID:        advisory.ID,
+				Aliases:   advisory.Aliases,
+				Severity:  severity,
+				FixedIn:   fixedIn,
+				Summary:   advisory.Summary,
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := severityRanks[findings[i].Severity], severityRanks[findings[j].Severity]
+		if a != b {
+			return a > b
+		}
+		if findings[i].Component.Name != findings[j].Component.Name {
+			return findings[i].Component.Name < findings[j].Component.Name
+		}
+		return findings[i].ID < findings[j].ID
+	})
+
+	return findings
+}
+
+func isIgnored(advisory *Advisory, ignored map[string]bool) bool {
+	if ignored[strings.ToUpper(advisory.ID)] {
+		return true
+	}
+	for _, alias := range advisory.Aliases {
+		if ignored[strings.ToUpper(alias)] {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAdvisory checks a component against the affected entries of an
+// advisory for its package, returning the first fixed version above it and
+// the advisory's severity
+func matchAdvisory(advisory *Advisory, key string, component sbom.Component) (bool, string, string) {
+	for _, affected := range advisory.Affected {
+		if packageKey(affected.Package.Ecosystem, affected.Package.Name) != key {
+			continue
+		}
+
+		hit := false
+		for _, version := range affected.Versions {
+			if version == component.Version {
+				hit = true
+				break
+			}
+		}
+
+		fixedIn := ""
+		for _, r := range affected.Ranges {
+			if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+				continue
+			}
+
+			inRange, fixed := matchRange(component.Ecosystem, r.Events, component.Version)
+			if inRange {
+				hit = true
+				if fixedIn == "" {
+					fixedIn = fixed
+				}
+			}
+		}
+
+		if hit {
+			severity := advisorySeverity(advisory, affected.EcosystemSpecific.Severity)
+			return true, fixedIn, severity
+		}
+	}
+
+	return false, "", ""
+}
+
+type event struct {
+	version string
+	kind    string
+}
+
+// matchRange applies OSV range semantics: events are sorted by version and
+// the version is affected after an introduced event until a fixed (exclusive)
+// or last_affected (inclusive) event
+func matchRange(ecosystem string, rangeEvents []Event, version string) (bool, string) {
+	var events []event
+	for _, e := range rangeEvents {
+		switch {
+		case e.Introduced != "":
+			events = append(events, event{e.Introduced, "introduced"})
+		case e.Fixed != "":
+			events = append(events, event{e.Fixed, "fixed"})
+		case e.LastAffected != "":
+			events = append(events, event{e.LastAffected, "last_affected"})
+		}
+	}
+
+	compare := func(a, b string) int {
+		switch {
+		case a == "0" && b == "0":
+			return 0
+		case a == "0":
+			return -1
+		case b == "0":
+			return 1
+		}
+		return compareVersions(ecosystem, a, b)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return compare(events[i].version, events[j].version) < 0
+	})
+
+	affected := false
+	for i, e := range events {
+		c := compare(version, e.version)
+		switch e.kind {
+		case "introduced":
+			if c >= 0 {
+				affected = true
+			}
+		case "fixed":
+			if c >= 0 {
+				affected = false
+			} else if affected {
+				return true, e.version
+			}
+		case "last_affected":
+			if c > 0 {
+				affected = false
+			} else if affected {
+				return true, nextFixed(events[i+1:])
+			}
+		}
+		if c < 0 {
+			break
+		}
+	}
+
+	return affected, ""
+}
+
+func nextFixed(events []event) string {
+	for _, e := range events {
+		if e.kind == "fixed" {
+			return e.version
+		}
+	}
+	return ""
+}
+
+// advisorySeverity prefers the severity label published with the advisory
+// (GitHub's LOW to CRITICAL), then the ecosystem's, and finally derives one
+// from a CVSS v3 vector
+func advisorySeverity(advisory *Advisory, ecosystemSeverity string) string {
+	for _, label := range []string{advisory.DatabaseSpecific.Severity, ecosystemSeverity} {
+		label = strings.ToUpper(strings.TrimSpace(label))
+		if label == "MEDIUM" {
+			return Moderate
+		}
+		if _, ok := severityRanks[label]; ok && label != Unknown {
+			return label
+		}
+	}
+
+	for _, severity := range advisory.Severity {
+		if severity.Type != "CVSS_V3" {
+			continue
+		}
+		if score, ok := cvss3Score(severity.Score); ok {
+			return cvssSeverity(score)
+		}
+	}
+
+	return Unknown
+}
+
+// PrintFindings writes a table of the findings, most severe first
+func PrintFindings(w io.Writer, findings []Finding) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "SEVERITY\tPACKAGE\tVERSION\tADVISORY\tFIXED IN\tSUMMARY")
+	for _, finding := range findings {
+		fixedIn := finding.FixedIn
+		if fixedIn == "" {
+			fixedIn = "-"
+		}
+		id := finding.ID
+		for _, alias := range finding.Aliases {
+			if strings.HasPrefix(alias, "CVE-") {
+				id += " (" + alias + ")"
+				break
+			}
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", finding.Severity, finding.Component.Name, finding.Component.Version, id, fixedIn, truncate(finding.Summary, 60))
+	}
+	table.Flush()
+}
+
+// CountBySeverity returns a summary like "1 critical, 2 high"
+func CountBySeverity(findings []Finding) string {
+	counts := map[string]int{}
+	for _, finding := range findings {
+		counts[finding.Severity]++
+	}
+
+	var parts []string
+	for _, severity := range []string{Critical, High, Moderate, Low, Unknown} {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], strings.ToLower(severity)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
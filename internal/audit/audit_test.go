@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codeupify/upify/internal/sbom"
+)
+
+func TestMatchRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		ecosystem string
+		events    []Event
+		version   string
+		affected  bool
+		fixed     string
+	}{
+		{name: "introduced 0", ecosystem: sbom.Npm, events: []Event{{Introduced: "0"}}, version: "0.0.1", affected: true},
+		{name: "introduced 0 prerelease", ecosystem: sbom.Npm, events: []Event{{Introduced: "0"}, {Fixed: "1.0.0"}}, version: "0.0.0-alpha", affected: true, fixed: "1.0.0"},
+		{name: "before introduced", ecosystem: sbom.Npm, events: []Event{{Introduced: "1.2.0"}, {Fixed: "1.4.0"}}, version: "1.1.9"},
+		{name: "at introduced", ecosystem: sbom.Npm, events: []Event{{Introduced: "1.2.0"}, {Fixed: "1.4.0"}}, version: "1.2.0", affected: true, fixed: "1.4.0"},
+		{name: "below fixed", ecosystem: sbom.Npm, events: []Event{{Introduced: "1.2.0"}, {Fixed: "1.4.0"}}, version: "1.3.9", affected: true, fixed: "1.4.0"},
+		{name: "fixed prerelease", ecosystem: sbom.Npm, events: []Event{{Introduced: "1.2.0"}, {Fixed: "1.4.0"}}, version: "1.4.0-rc.1", affected: true, fixed: "1.4.0"},
+		{name: "at fixed", ecosystem: sbom.Npm, events: []Event{{Introduced: "1.2.0"}, {Fixed: "1.4.0"}}, version: "1.4.0"},
+		{name: "above fixed", ecosystem: sbom.Npm, events: []Event{{Introduced: "1.2.0"}, {Fixed: "1.4.0"}}, version: "2.0.0"},
+		{name: "at last_affected", ecosystem: sbom.Npm, events: []Event{{Introduced: "0"}, {LastAffected: "1.4.0"}}, version: "1.4.0", affected: true},
+		{name: "above last_affected", ecosystem: sbom.Npm, events: []Event{{Introduced: "0"}, {LastAffected: "1.4.0"}}, version: "1.4.1"},
+		{name: "last_affected then fixed", ecosystem: sbom.Npm, events: []Event{{Introduced: "0"}, {LastAffected: "1.4.0"}, {Fixed: "1.5.0"}}, version: "1.3.0", affected: true, fixed: "1.5.0"},
+		{name: "unsorted events", ecosystem: sbom.Npm, events: []Event{{Fixed: "1.4.0"}, {Introduced: "1.2.0"}}, version: "1.3.0", affected: true, fixed: "1.4.0"},
+		{name: "second range", ecosystem: sbom.Npm, events: []Event{{Introduced: "0"}, {Fixed: "1.0.0"}, {Introduced: "2.0.0"}, {Fixed: "2.5.0"}}, version: "2.1.0", affected: true, fixed: "2.5.0"},
+		{name: "between ranges", ecosystem: sbom.Npm, events: []Event{{Introduced: "0"}, {Fixed: "1.0.0"}, {Introduced: "2.0.0"}, {Fixed: "2.5.0"}}, version: "1.5.0"},
+		{name: "after ranges", ecosystem: sbom.Npm, events: []Event{{Introduced: "0"}, {Fixed: "1.0.0"}, {Introduced: "2.0.0"}, {Fixed: "2.5.0"}}, version: "2.5.0"},
+		{name: "pypi fixed", ecosystem: sbom.PyPI, events: []Event{{Introduced: "0"}, {Fixed: "2.0"}}, version: "2.0rc1", affected: true, fixed: "2.0"},
+		{name: "pypi post release", ecosystem: sbom.PyPI, events: []Event{{Introduced: "0"}, {Fixed: "2.0"}}, version: "2.0.post1"},
+		{name: "pypi dev release", ecosystem: sbom.PyPI, events: []Event{{Introduced: "1.0"}, {Fixed: "2.0"}}, version: "1.0.dev1"},
+		{name: "pypi local version", ecosystem: sbom.PyPI, events: []Event{{Introduced: "0"}, {LastAffected: "2.0"}}, version: "2.0+local.1"},
+		{name: "pypi epoch", ecosystem: sbom.PyPI, events: []Event{{Introduced: "0"}, {Fixed: "3.0"}}, version: "1!1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			affected, fixed := matchRange(tt.ecosystem, tt.events, tt.version)
+			if affected != tt.affected || fixed != tt.fixed {
+				t.Errorf("matchRange(%s) = %v, %q, want %v, %q", tt.version, affected, fixed, tt.affected, tt.fixed)
+			}
+		})
+	}
+}
+
+const advisories = `{
+  "id": "GHSA-1111",
+  "aliases": ["CVE-2024-1"],
+  "summary": "Template injection",
+  "affected": [{
+    "package": {"ecosystem": "PyPI", "name": "Jinja2"},
+    "ranges": [
+      {"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "2.11.3"}]},
+      {"type": "ECOSYSTEM", "events": [{"introduced": "3.0.0"}, {"fixed": "3.1.4"}]}
+    ]
+  }],
+  "database_specific": {"severity": "HIGH"}
+}
+---
+{
+  "id": "GHSA-2222",
+  "summary": "Prototype pollution",
+  "affected": [
+    {
+      "package": {"ecosystem": "npm", "name": "lodash"},
+      "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"last_affected": "4.17.20"}]}]
+    },
+    {
+      "package": {"ecosystem": "npm", "name": "lodash-es"},
+      "versions": ["4.17.15"]
+    }
+  ],
+  "database_specific": {"severity": "CRITICAL"}
+}
+---
+{
+  "id": "GHSA-3333",
+  "summary": "Git commit range",
+  "affected": [{
+    "package": {"ecosystem": "npm", "name": "lodash"},
+    "ranges": [{"type": "GIT", "events": [{"introduced": "0"}, {"fixed": "abc123"}]}]
+  }]
+}
+---
+{
+  "id": "GHSA-4444",
+  "withdrawn": "2024-01-01T00:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "npm", "name": "lodash"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]
+  }]
+}`
+
+func TestAudit(t *testing.T) {
+	components := []sbom.Component{
+		{Name: "jinja2", Version: "2.10", Ecosystem: sbom.PyPI},
+		{Name: "Jinja2", Version: "3.1.2", Ecosystem: sbom.PyPI},
+		{Name: "jinja2", Version: "3.1.4", Ecosystem: sbom.PyPI},
+		{Name: "lodash", Version: "4.17.20", Ecosystem: sbom.Npm},
+		{Name: "lodash", Version: "4.17.21", Ecosystem: sbom.Npm},
+		{Name: "lodash-es", Version: "4.17.15", Ecosystem: sbom.Npm},
+		{Name: "lodash", Version: "^4.17.0", Ecosystem: sbom.Npm, Declared: true},
+	}
+
+	dir := t.TempDir()
+	for i, record := range strings.Split(advisories, "\n---\n") {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", i)), []byte(record), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := LoadDatabase(dir, WantedPackages(components))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Count != 3 {
+		t.Errorf("loaded %d advisories, want 3 without the withdrawn one", db.Count)
+	}
+
+	type result struct {
+		Name, Version, ID, Severity, FixedIn string
+	}
+	var got []result
+	for _, finding := range Audit(db, components, nil) {
+		got = append(got, result{finding.Component.Name, finding.Component.Version, finding.ID, finding.Severity, finding.FixedIn})
+	}
+
+	want := []result{
+		{"lodash", "4.17.20", "GHSA-2222", Critical, ""},
+		{"lodash-es", "4.17.15", "GHSA-2222", Critical, ""},
+		{"Jinja2", "3.1.2", "GHSA-1111", High, "3.1.4"},
+		{"jinja2", "2.10", "GHSA-1111", High, "2.11.3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %+v\nwant %+v", got, want)
+	}
+
+	// Advisories can be ignored by ID or alias
+	findings := Audit(db, components, []string{"cve-2024-1", "GHSA-2222"})
+	if len(findings) != 0 {
+		t.Errorf("got %d findings with both advisories ignored", len(findings))
+	}
+
+	unpinned := Unpinned(components)
+	if len(unpinned) != 1 || unpinned[0].Version != "^4.17.0" {
+		t.Errorf("Unpinned() = %+v, want the ^4.17.0 range", unpinned)
+	}
+}
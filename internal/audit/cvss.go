@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"math"
+	"strings"
+)
+
+// cvss3Weights are the CVSS v3.x base metric weights. Privileges required has
+// different weights when the scope changes, see cvss3Score
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// cvss3Score computes the base score of a CVSS v3.0 or v3.1 vector such as
+// CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H
+func cvss3Score(vector string) (float64, bool) {
+	if !strings.HasPrefix(vector, "CVSS:3") {
+		return 0, false
+	}
+
+	metrics := map[string]string{}
+	for _, part := range strings.Split(vector, "/")[1:] {
+		name, value, ok := strings.Cut(part, ":")
+		if ok {
+			metrics[name] = value
+		}
+	}
+
+	weights := map[string]float64{}
+	for name, values := range cvss3Weights {
+		weight, ok := values[metrics[name]]
+		if !ok {
+			return 0, false
+		}
+		weights[name] = weight
+	}
+
+	changed := metrics["S"] == "C"
+	if changed {
+		switch metrics["PR"] {
+		case "L":
+			weights["PR"] = 0.68
+		case "H":
+			weights["PR"] = 0.5
+		}
+	}
+
+	iss := 1 - (1-weights["C"])*(1-weights["I"])*(1-weights["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+
+	exploitability := 8.22 * weights["AV"] * weights["AC"] * weights["PR"] * weights["UI"]
+	score := impact + exploitability
+	if changed {
+		score *= 1.08
+	}
+
+	return roundUp(math.Min(score, 10)), true
+}
+
+// roundUp rounds to one decimal place upwards as defined by CVSS v3.1
+func roundUp(value float64) float64 {
+	scaled := int64(math.Round(value * 100000))
+	if scaled%10000 == 0 {
+		return float64(scaled) / 100000
+	}
+	return float64(scaled/10000+1) / 10
+}
+
+func cvssSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return Critical
+	case score >= 7:
+		return High
+	case score >= 4:
+		return Moderate
+	case score > 0:
+		return Low
+	default:
+		return Unknown
+	}
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Advisory is the subset of an OSV record (https://ossf.github.io/osv-schema)
+// needed to match installed packages
+type Advisory struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases"`
+	Summary   string   `json:"summary"`
+	Withdrawn string   `json:"withdrawn"`
+	Severity  []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string  `json:"type"`
+			Events []Event `json:"events"`
+		} `json:"ranges"`
+		Versions          []string `json:"versions"`
+		EcosystemSpecific struct {
+			Severity string `json:"severity"`
+		} `json:"ecosystem_specific"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// Event is a point in an affected range where a vulnerability was introduced
+// or fixed
+type Event struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+}
+
+// Database is a set of advisories indexed by ecosystem and package name
+type Database struct {
+	advisories map[string][]*Advisory
+	Count      int
+}
+
+// LoadDatabase reads an OSV dump from disk: either a directory of OSV JSON
+// records (searched recursively) or a zip of them, like the all.zip exports
+// published per ecosystem. Advisories are only kept for the given packages
+// (see packageKey) to keep memory use down
+func LoadDatabase(path string, wanted map[string]bool) (*Database, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("advisory database not found at %s: %v", path, err)
+	}
+
+	db := &Database{advisories: map[string][]*Advisory{}}
+
+	if !info.IsDir() {
+		return db, db.loadZip(path, wanted)
+	}
+
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			return nil
+		case strings.HasSuffix(info.Name(), ".zip"):
+			return db.loadZip(p, wanted)
+		case strings.HasSuffix(info.Name(), ".json"):
+			file, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			return db.add(file, p, wanted)
+		}
+
+		return nil
+	})
+
+	return db, err
+}
+
+func (db *Database) loadZip(path string, wanted map[string]bool) error {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if !strings.HasSuffix(file.Name, ".json") {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = db.add(rc, path+":"+file.Name, wanted)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) add(r io.Reader, name string, wanted map[string]bool) error {
+	var advisory Advisory
+	if err := json.NewDecoder(r).Decode(&advisory); err != nil {
+		return fmt.Errorf("failed to parse advisory %s: %v", name, err)
+	}
+
+	if advisory.Withdrawn != "" {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for _, affected := range advisory.Affected {
+		key := packageKey(affected.Package.Ecosystem, affected.Package.Name)
+		if !wanted[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		db.advisories[key] = append(db.advisories[key], &advisory)
+	}
+
+	if len(seen) > 0 {
+		db.Count++
+	}
+
+	return nil
+}
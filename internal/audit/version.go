@@ -0,0 +1,233 @@
+package audit
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/codeupify/upify/internal/sbom"
+)
+
+var (
+	pep440Pattern    = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)(?:[-_.]?(a|alpha|b|beta|c|rc|pre|preview)[-_.]?(\d*))?(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?(?:[-_.]?(dev)[-_.]?(\d*))?(?:\+(.+))?$`)
+	pythonSeparators = regexp.MustCompile(`[-_.]+`)
+	digits           = regexp.MustCompile(`\d+`)
+)
+
+// packageKey identifies a package across the advisory database and the
+// components found in an artifact
+func packageKey(ecosystem string, name string) string {
+	switch strings.ToLower(ecosystem) {
+	case "pypi":
+		return sbom.PyPI + "/" + strings.ToLower(pythonSeparators.ReplaceAllString(name, "-"))
+	case "npm":
+		return sbom.Npm + "/" + name
+	default:
+		return strings.ToLower(ecosystem) + "/" + name
+	}
+}
+
+// compareVersions compares two versions with the ecosystem's rules: PEP 440
+// for PyPI and SemVer for npm. It returns -1, 0 or 1
+func compareVersions(ecosystem string, a string, b string) int {
+	if ecosystem == sbom.PyPI {
+		if c := compareKeys(pep440Key(a), pep440Key(b)); c != 0 {
+			return c
+		}
+		return compareLocal(pep440Local(a), pep440Local(b))
+	}
+	return compareSemver(a, b)
+}
+
+const (
+	minPart = -1 << 40
+	maxPart = 1 << 40
+)
+
+// pep440Key turns a PEP 440 version into a list of integers that sort in
+// version order: epoch, release segments (padded), pre-release, post-release
+// and dev-release
+func pep440Key(version string) []int {
+	match := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(version)))
+	if match == nil {
+		return fallbackKey(version)
+	}
+
+	key := []int{atoi(match[1])}
+
+	release := strings.Split(match[2], ".")
+	for i := 0; i < 10; i++ {
+		if i < len(release) {
+			key = append(key, atoi(release[i]))
+		} else {
+			key = append(key, 0)
+		}
+	}
+
+	pre := match[3]
+	hasPost, post := match[5] != "" || match[6] != "", match[5]+match[7]
+	hasDev, dev := match[8] != "", match[9]
+
+	switch {
+	case pre != "":
+		phase := map[string]int{"a": 0, "alpha": 0, "b": 1, "beta": 1, "c": 2, "rc": 2, "pre": 2, "preview": 2}[pre]
+		key = append(key, phase, atoi(match[4]))
+	case hasDev && !hasPost:
+		// 1.0.dev1 sorts before 1.0a1
+		key = append(key, minPart, 0)
+	default:
+		key = append(key, maxPart, 0)
+	}
+
+	if hasPost {
+		key = append(key, atoi(post))
+	} else {
+		key = append(key, minPart)
+	}
+
+	if hasDev {
+		key = append(key, atoi(dev))
+	} else {
+		key = append(key, maxPart)
+	}
+
+	return key
+}
+
+// pep440Local returns the segments of a PEP 440 local version label, the
+// part after the +
+func pep440Local(version string) []string {
+	match := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(version)))
+	if match == nil || match[10] == "" {
+		return nil
+	}
+	return pythonSeparators.Split(match[10], -1)
+}
+
+// compareLocal orders local version labels: a version with one sorts after
+// the same version without, numeric segments compare as numbers and above
+// alphanumeric ones, and a label that extends another sorts after it
+func compareLocal(a []string, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		numA, errA := strconv.Atoi(a[i])
+		numB, errB := strconv.Atoi(b[i])
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				return sign(numA - numB)
+			}
+		case errA == nil:
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return sign(len(a) - len(b))
+}
+
+func compareSemver(a string, b string) int {
+	mainA, preA := splitSemver(a)
+	mainB, preB := splitSemver(b)
+
+	if c := compareKeys(mainA, mainB); c != 0 {
+		return c
+	}
+
+	// A version without a pre-release is greater than one with it
+	switch {
+	case len(preA) == 0 && len(preB) == 0:
+		return 0
+	case len(preA) == 0:
+		return 1
+	case len(preB) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(preA) && i < len(preB); i++ {
+		numA, errA := strconv.Atoi(preA[i])
+		numB, errB := strconv.Atoi(preB[i])
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				return sign(numA - numB)
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(preA[i], preB[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return sign(len(preA) - len(preB))
+}
+
+func splitSemver(version string) ([]int, []string) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	version = strings.SplitN(version, "+", 2)[0]
+
+	main, pre, _ := strings.Cut(version, "-")
+
+	var key []int
+	for _, part := range strings.Split(main, ".") {
+		key = append(key, atoi(part))
+	}
+	for len(key) < 3 {
+		key = append(key, 0)
+	}
+
+	var preParts []string
+	if pre != "" {
+		preParts = strings.Split(pre, ".")
+	}
+
+	return key, preParts
+}
+
+func fallbackKey(version string) []int {
+	var key []int
+	for _, part := range digits.FindAllString(version, -1) {
+		key = append(key, atoi(part))
+	}
+	return key
+}
+
+func compareKeys(a []int, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return sign(x - y)
+		}
+	}
+	return 0
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/codeupify/upify/internal/sbom"
+)
+
+func TestComparePEP440(t *testing.T) {
+	// In ascending order, from the examples in PEP 440
+	ordered := []string{
+		"0.9",
+		"1.0.dev456",
+		"1.0a1",
+		"1.0a2.dev456",
+		"1.0a12.dev456",
+		"1.0a12",
+		"1.0b1.dev456",
+		"1.0b2",
+		"1.0b2.post345.dev456",
+		"1.0b2.post345",
+		"1.0rc1.dev456",
+		"1.0rc1",
+		"1.0",
+		"1.0+abc.5",
+		"1.0+abc.7",
+		"1.0+5",
+		"1.0+5.1",
+		"1.0.post456.dev34",
+		"1.0.post456",
+		"1.0.15",
+		"1.1.dev1",
+		"1.10",
+		"2024.1",
+		"1!0.1",
+		"1!1.0",
+	}
+
+	for i := 0; i+1 < len(ordered); i++ {
+		a, b := ordered[i], ordered[i+1]
+		if c := compareVersions(sbom.PyPI, a, b); c != -1 {
+			t.Errorf("compareVersions(%q, %q) = %d, want -1", a, b, c)
+		}
+		if c := compareVersions(sbom.PyPI, b, a); c != 1 {
+			t.Errorf("compareVersions(%q, %q) = %d, want 1", b, a, c)
+		}
+	}
+
+	equal := [][2]string{
+		{"1.0", "1.0.0"},
+		{"1.0", "v1.0"},
+		{"1.0RC1", "1.0rc1"},
+		{"1.0c1", "1.0rc1"},
+		{"1.0alpha1", "1.0a1"},
+		{"1.0-beta.2", "1.0b2"},
+		{"1.0-1", "1.0.post1"},
+		{"1.0.post", "1.0.post0"},
+		{"1.0.dev", "1.0.dev0"},
+		{"0!1.0", "1.0"},
+		{"1.0+ABC", "1.0+abc"},
+		{"1.0+abc-5", "1.0+abc.5"},
+	}
+	for _, pair := range equal {
+		if c := compareVersions(sbom.PyPI, pair[0], pair[1]); c != 0 {
+			t.Errorf("compareVersions(%q, %q) = %d, want 0", pair[0], pair[1], c)
+		}
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	// In ascending order, from the SemVer 2.0.0 precedence rules
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.9.0",
+		"1.10.0",
+		"2.0.0-0",
+		"2.0.0",
+	}
+
+	for i := 0; i+1 < len(ordered); i++ {
+		a, b := ordered[i], ordered[i+1]
+		if c := compareVersions(sbom.Npm, a, b); c != -1 {
+			t.Errorf("compareVersions(%q, %q) = %d, want -1", a, b, c)
+		}
+		if c := compareVersions(sbom.Npm, b, a); c != 1 {
+			t.Errorf("compareVersions(%q, %q) = %d, want 1", b, a, c)
+		}
+	}
+
+	equal := [][2]string{
+		{"1.0.0", "v1.0.0"},
+		{"1.0.0", "1.0.0+build.5"},
+		{"1.0.0-rc.1+build.5", "1.0.0-rc.1"},
+		{"1.2", "1.2.0"},
+	}
+	for _, pair := range equal {
+		if c := compareVersions(sbom.Npm, pair[0], pair[1]); c != 0 {
+			t.Errorf("compareVersions(%q, %q) = %d, want 0", pair[0], pair[1], c)
+		}
+	}
+}
+
+func TestPackageKey(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		name      string
+		want      string
+	}{
+		{"PyPI", "Django", "pypi/django"},
+		{"pypi", "zope.interface", "pypi/zope-interface"},
+		{"PyPI", "Foo__Bar-.baz", "pypi/foo-bar-baz"},
+		{"npm", "@Scope/Pkg", "npm/@Scope/Pkg"},
+		{"npm", "lodash", "npm/lodash"},
+		{"Go", "golang.org/x/net", "go/golang.org/x/net"},
+	}
+
+	for _, tt := range tests {
+		if got := packageKey(tt.ecosystem, tt.name); got != tt.want {
+			t.Errorf("packageKey(%q, %q) = %q, want %q", tt.ecosystem, tt.name, got, tt.want)
+		}
+	}
+}
@@ -22,6 +22,106 @@ type Config struct {
 	PackageManager lang.PackageManager `yaml:"package_manager"`
 	Entrypoint     string              `yaml:"entrypoint,omitempty"`
 	AppVar         string              `yaml:"app_var,omitempty"`
+	SourceDir      string              `yaml:"source_dir,omitempty"`
+	IncludePaths   []string            `yaml:"include_paths,omitempty"`
+	PackageType    string              `yaml:"package_type,omitempty"`
+	Image          *ImageConfig        `yaml:"image,omitempty"`
+	Bundle         *BundleConfig       `yaml:"bundle,omitempty"`
+	Python         *PythonConfig       `yaml:"python,omitempty"`
+	Offline        bool                `yaml:"offline,omitempty"`
+	SBOM           *SBOMConfig         `yaml:"sbom,omitempty"`
+	Licenses       *LicensesConfig     `yaml:"licenses,omitempty"`
+	Audit          *AuditConfig        `yaml:"audit,omitempty"`
+}
+
+// ImageConfig is used when package_type is image. Base is a tarball of the
+// base image, as written by `docker save` or `skopeo copy ... oci-archive:`.
+// Deploys push the image to Repository, over plain HTTP if Insecure is set
+type ImageConfig struct {
+	Base       string `yaml:"base"`
+	Repository string `yaml:"repository,omitempty"`
+	Insecure   bool   `yaml:"insecure,omitempty"`
+}
+
+// BundleConfig enables esbuild bundling for JavaScript and TypeScript
+// projects, shipping a single minified upify_handler.js instead of the whole
+// node_modules tree. Native modules are detected and kept external
+// automatically, Externals lists any additional packages to leave unbundled
+type BundleConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	Externals []string `yaml:"externals,omitempty"`
+}
+
+// PythonConfig pins the interpreter used to install dependencies. When
+// Interpreter is empty the active or in-project virtualenv is used, falling
+// back to python3 on the PATH
+type PythonConfig struct {
+	Interpreter string `yaml:"interpreter,omitempty"`
+}
+
+// SBOMConfig selects the format of the SBOM written next to every artifact,
+// cyclonedx (the default) or spdx
+type SBOMConfig struct {
+	Format string `yaml:"format,omitempty"`
+}
+
+// LicensesConfig is the license policy for packages shipped in the artifact.
+// Mode is fail (the default) or warn. Exceptions approve packages whose
+// license would otherwise be rejected
+type LicensesConfig struct {
+	Allow      []string           `yaml:"allow,omitempty"`
+	Deny       []string           `yaml:"deny,omitempty"`
+	Mode       string             `yaml:"mode,omitempty"`
+	Exceptions []LicenseException `yaml:"exceptions,omitempty"`
+}
+
+type LicenseException struct {
+	Package string `yaml:"package"`
+	Version string `yaml:"version,omitempty"`
+	Reason  string `yaml:"reason,omitempty"`
+}
+
+// AuditConfig points at an OSV advisory dump on disk (a directory of JSON
+// records or a zip). With Gate set, packaging fails when a package in the
+// artifact has an advisory at or above Severity (high by default). Ignore
+// lists advisory IDs or aliases that have been reviewed
+type AuditConfig struct {
+	Database string   `yaml:"database,omitempty"`
+	Gate     bool     `yaml:"gate,omitempty"`
+	Severity string   `yaml:"severity,omitempty"`
+	Ignore   []string `yaml:"ignore,omitempty"`
+}
+
+// GetSourceDir returns the directory that gets staged into the artifact,
+// relative to the directory containing .upify. The entrypoint and upify
+// handler files live in it
+func (c *Config) GetSourceDir() string {
+	if c.SourceDir == "" {
+		return "."
+	}
+	return filepath.Clean(c.SourceDir)
+}
+
+func (c *Config) ImagePackaging() bool {
+	return c.PackageType == "image"
+}
+
+func (c *Config) BundleEnabled() bool {
+	return c.Bundle != nil && c.Bundle.Enabled
+}
+
+func (c *Config) SBOMFormat() string {
+	if c.SBOM == nil {
+		return ""
+	}
+	return c.SBOM.Format
+}
+
+func (c *Config) PythonInterpreter() string {
+	if c.Python == nil {
+		return ""
+	}
+	return c.Python.Interpreter
 }
 
 func GetConfigFilePath() string {
@@ -42,6 +142,7 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+
 	return &cfg, nil
 }
 
@@ -3,6 +3,9 @@ package fs
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -11,8 +14,7 @@ import (
 	"github.com/otiai10/copy"
 )
 
-var excludedDirs = []string{".git", "node_modules", "venv", ".upify", "dist"}
-var excludedFiles = []string{"package-lock.json", "yarn.lock"}
+var excludedDirs = []string{".git", "node_modules", "venv", ".venv", ".upify", "dist"}
 
 func CopyFilesToTempDir(srcDir, destDir string) error {
 	opts := copy.Options{
@@ -31,12 +33,6 @@ func CopyFilesToTempDir(srcDir, destDir string) error {
 				}
 			}
 
-			for _, exclude := range excludedFiles {
-				if relPath == exclude || filepath.Base(relPath) == exclude {
-					return true, nil
-				}
-			}
-
 			return false, nil
 		},
 	}
@@ -44,6 +40,14 @@ func CopyFilesToTempDir(srcDir, destDir string) error {
 	return copy.Copy(srcDir, destDir, opts)
 }
 
+func CopyDir(srcDir, destDir string) error {
+	return copy.Copy(srcDir, destDir, copy.Options{
+		OnSymlink: func(src string) copy.SymlinkAction {
+			return copy.Deep
+		},
+	})
+}
+
 func CreateZip(sourceDir string, destFile string) error {
 	buffer := new(bytes.Buffer)
 	zipWriter := zip.NewWriter(buffer)
@@ -100,3 +104,161 @@ func CreateZip(sourceDir string, destFile string) error {
 
 	return os.WriteFile(destFile, buffer.Bytes(), 0o644)
 }
+
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ListFiles returns the slash-separated paths of all files under dir,
+// relative to dir
+func ListFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relPath))
+		return nil
+	})
+
+	return files, err
+}
+
+// CopyPackageDir copies a local package into destDir, skipping version
+// control and installed dependencies
+func CopyPackageDir(srcDir, destDir string) error {
+	return copy.Copy(srcDir, destDir, copy.Options{
+		OnSymlink: func(src string) copy.SymlinkAction {
+			return copy.Deep
+		},
+		Skip: func(srcinfo os.FileInfo, src string, dest string) (bool, error) {
+			name := srcinfo.Name()
+			return srcinfo.IsDir() && (name == ".git" || name == "node_modules" || name == "__pycache__"), nil
+		},
+	})
+}
+
+// ResolveSymlinks replaces every symlink under root with a copy of its
+// target, since symlinks (e.g. to workspace packages in node_modules) don't
+// survive being zipped and uploaded. Dangling symlinks are removed, and so
+// are symlinks to a directory containing them, which would copy forever
+func ResolveSymlinks(root string) error {
+	var links []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			links = append(links, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		parent, err := filepath.EvalSymlinks(filepath.Dir(link))
+		if err != nil {
+			return err
+		}
+
+		target, targetErr := filepath.EvalSymlinks(link)
+		if err := os.Remove(link); err != nil {
+			return err
+		}
+		if targetErr != nil {
+			continue
+		}
+
+		if err := copyResolved(target, link, []string{parent}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyResolved copies src to dest, following symlinks. ancestors holds the
+// real paths of the directories being copied into, a symlink to one of them
+// or to a directory containing one is a cycle and is left out
+func copyResolved(src string, dest string, ancestors []string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		// Dangling
+		return nil
+	}
+
+	if !info.IsDir() {
+		return copyFile(src, dest, info.Mode())
+	}
+
+	realSrc, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return err
+	}
+
+	for _, ancestor := range ancestors {
+		if ancestor == realSrc || strings.HasPrefix(ancestor, realSrc+string(filepath.Separator)) {
+			fmt.Printf("Warning: leaving out %s, a symlink cycle back to %s\n", dest, realSrc)
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(dest, info.Mode().Perm()|0700); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	ancestors = append(ancestors[:len(ancestors):len(ancestors)], realSrc)
+	for _, entry := range entries {
+		if err := copyResolved(filepath.Join(src, entry.Name()), filepath.Join(dest, entry.Name()), ancestors); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(src string, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,219 @@
+package fs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type SizeEntry struct {
+	Name string
+	Size int64
+}
+
+type SizeReport struct {
+	Total       int64
+	Directories []SizeEntry
+	Packages    []SizeEntry
+}
+
+func DirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, err
+}
+
+// AnalyzeDir walks a staging directory and returns the largest directories
+// (up to two levels deep) and the largest installed Python and Node.js
+// packages, each sorted by size and truncated to limit entries
+func AnalyzeDir(dir string, limit int) (*SizeReport, error) {
+	dirSizes := map[string]int64{}
+	var total int64
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		total += info.Size()
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		parts := strings.Split(filepath.ToSlash(relPath), "/")
+		for depth := 1; depth < len(parts) && depth <= 2; depth++ {
+			dirSizes[strings.Join(parts[:depth], "/")] += info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SizeReport{Total: total}
+	for name, size := range dirSizes {
+		report.Directories = append(report.Directories, SizeEntry{Name: name + "/", Size: size})
+	}
+
+	nodePackages, err := nodePackageSizes(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pythonPackages, err := pythonPackageSizes(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Packages = append(nodePackages, pythonPackages...)
+	report.Directories = largest(report.Directories, limit)
+	report.Packages = largest(report.Packages, limit)
+
+	return report, nil
+}
+
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+func nodePackageSizes(dir string) ([]SizeEntry, error) {
+	nodeModules := filepath.Join(dir, "node_modules")
+	entries, err := os.ReadDir(nodeModules)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []SizeEntry
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		if strings.HasPrefix(entry.Name(), "@") {
+			scoped, err := os.ReadDir(filepath.Join(nodeModules, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			for _, pkg := range scoped {
+				name := entry.Name() + "/" + pkg.Name()
+				size, err := DirSize(filepath.Join(nodeModules, name))
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, SizeEntry{Name: name, Size: size})
+			}
+			continue
+		}
+
+		size, err := DirSize(filepath.Join(nodeModules, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, SizeEntry{Name: entry.Name(), Size: size})
+	}
+
+	return result, nil
+}
+
+// pythonPackageSizes attributes top-level directories installed with
+// `pip install -t` to the distribution that owns them, using top_level.txt
+// from each dist-info directory
+func pythonPackageSizes(dir string) ([]SizeEntry, error) {
+	distInfos, err := filepath.Glob(filepath.Join(dir, "*.dist-info"))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []SizeEntry
+	for _, distInfo := range distInfos {
+		name := strings.SplitN(strings.TrimSuffix(filepath.Base(distInfo), ".dist-info"), "-", 2)[0]
+
+		size, err := DirSize(distInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, topLevel := range readTopLevel(distInfo, name) {
+			for _, candidate := range []string{topLevel, topLevel + ".py"} {
+				path := filepath.Join(dir, candidate)
+				if _, err := os.Stat(path); err != nil {
+					continue
+				}
+				pkgSize, err := DirSize(path)
+				if err != nil {
+					return nil, err
+				}
+				size += pkgSize
+			}
+		}
+
+		result = append(result, SizeEntry{Name: name, Size: size})
+	}
+
+	return result, nil
+}
+
+func readTopLevel(distInfo string, fallback string) []string {
+	file, err := os.Open(filepath.Join(distInfo, "top_level.txt"))
+	if err != nil {
+		return []string{strings.ToLower(fallback)}
+	}
+	defer file.Close()
+
+	var result []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			result = append(result, line)
+		}
+	}
+
+	return result
+}
+
+func largest(entries []SizeEntry, limit int) []SizeEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size == entries[j].Size {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Size > entries[j].Size
+	})
+
+	if limit > 0 && len(entries) > limit {
+		return entries[:limit]
+	}
+
+	return entries
+}
@@ -0,0 +1,504 @@
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	MediaTypeManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeIndex     = "application/vnd.oci.image.index.v1+json"
+	MediaTypeConfig    = "application/vnd.oci.image.config.v1+json"
+	MediaTypeLayer     = "application/vnd.oci.image.layer.v1.tar"
+	MediaTypeLayerGzip = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+	dockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// Descriptor points at a blob by digest, as in OCI manifests and indexes
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+type manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+type index struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Manifests     []Descriptor `json:"manifests"`
+}
+
+// Layer is a filesystem layer blob stored on disk
+type Layer struct {
+	Descriptor
+	DiffID string
+	Path   string
+}
+
+// Image is a single-platform image whose blobs live in a work directory. The
+// config is kept as a generic map so fields upify doesn't touch are carried
+// over from the base image unchanged
+type Image struct {
+	Config map[string]interface{}
+	Layers []Layer
+	dir    string
+}
+
+// RuntimeConfig is what upify sets on top of the base image's config. The
+// staged app is copied to AppDir
+type RuntimeConfig struct {
+	AppDir     string
+	WorkingDir string
+	Entrypoint []string
+	Cmd        []string
+	Env        map[string]string
+}
+
+// LoadBase reads a base image tarball, either an OCI image layout (as written
+// by `docker save` since Docker 25, skopeo or crane) or a legacy `docker save`
+// archive. For multi-platform images the manifest matching arch is used.
+// Blobs are extracted into workDir, which must outlive the image
+func LoadBase(path string, arch string, workDir string) (*Image, error) {
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := extractTar(path, workDir); err != nil {
+		return nil, fmt.Errorf("failed to read base image %s: %v", path, err)
+	}
+
+	img := &Image{dir: workDir}
+	var err error
+	if _, statErr := os.Stat(filepath.Join(workDir, "index.json")); statErr == nil {
+		err = img.loadLayout(arch)
+	} else if _, statErr := os.Stat(filepath.Join(workDir, "manifest.json")); statErr == nil {
+		err = img.loadDockerArchive()
+	} else {
+		err = fmt.Errorf("neither index.json nor manifest.json found, is it an image tarball?")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base image %s: %v", path, err)
+	}
+
+	return img, nil
+}
+
+func (img *Image) loadLayout(arch string) error {
+	var idx index
+	if err := readJSON(filepath.Join(img.dir, "index.json"), &idx); err != nil {
+		return err
+	}
+
+	desc, err := img.selectManifest(idx.Manifests, arch)
+	if err != nil {
+		return err
+	}
+
+	var m manifest
+	if err := readJSON(img.blobPath(desc.Digest), &m); err != nil {
+		return err
+	}
+
+	if err := readJSON(img.blobPath(m.Config.Digest), &img.Config); err != nil {
+		return err
+	}
+
+	diffIDs := img.diffIDs()
+	if len(diffIDs) != len(m.Layers) {
+		return fmt.Errorf("config lists %d layers but the manifest has %d", len(diffIDs), len(m.Layers))
+	}
+
+	for i, layer := range m.Layers {
+		layer.MediaType = ociLayerType(layer.MediaType)
+		img.Layers = append(img.Layers, Layer{Descriptor: layer, DiffID: diffIDs[i], Path: img.blobPath(layer.Digest)})
+	}
+
+	return nil
+}
+
+// selectManifest follows nested indexes down to the manifest for arch
+func (img *Image) selectManifest(manifests []Descriptor, arch string) (Descriptor, error) {
+	var candidates []Descriptor
+	for _, desc := range manifests {
+		if desc.Platform != nil && (desc.Platform.Architecture == "unknown" || desc.Platform.OS != "linux") {
+			// Attestations and other non-runnable entries
+			continue
+		}
+		candidates = append(candidates, desc)
+	}
+
+	if len(candidates) == 0 {
+		return Descriptor{}, fmt.Errorf("no image manifest found")
+	}
+
+	chosen := candidates[0]
+	for _, desc := range candidates {
+		if desc.Platform != nil && desc.Platform.Architecture == arch {
+			chosen = desc
+			break
+		}
+	}
+
+	if chosen.MediaType == MediaTypeIndex || chosen.MediaType == dockerManifestList {
+		var idx index
+		if err := readJSON(img.blobPath(chosen.Digest), &idx); err != nil {
+			return Descriptor{}, err
+		}
+		return img.selectManifest(idx.Manifests, arch)
+	}
+
+	if chosen.Platform != nil && chosen.Platform.Architecture != arch {
+		fmt.Printf("Warning: base image has no %s variant, using %s\n", arch, chosen.Platform.Architecture)
+	}
+
+	return chosen, nil
+}
+
+func (img *Image) loadDockerArchive() error {
+	var entries []struct {
+		Config string
+		Layers []string
+	}
+	if err := readJSON(filepath.Join(img.dir, "manifest.json"), &entries); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("manifest.json lists no images")
+	}
+
+	if err := readJSON(filepath.Join(img.dir, filepath.FromSlash(entries[0].Config)), &img.Config); err != nil {
+		return err
+	}
+
+	diffIDs := img.diffIDs()
+	if len(diffIDs) != len(entries[0].Layers) {
+		return fmt.Errorf("config lists %d layers but the manifest has %d", len(diffIDs), len(entries[0].Layers))
+	}
+
+	for i, layerPath := range entries[0].Layers {
+		path := filepath.Join(img.dir, filepath.FromSlash(layerPath))
+		digest, size, err := digestFile(path)
+		if err != nil {
+			return err
+		}
+
+		mediaType := MediaTypeLayer
+		if isGzip(path) {
+			mediaType = MediaTypeLayerGzip
+		}
+
+		img.Layers = append(img.Layers, Layer{
+			Descriptor: Descriptor{MediaType: mediaType, Digest: digest, Size: size},
+			DiffID:     diffIDs[i],
+			Path:       path,
+		})
+	}
+
+	return nil
+}
+
+// Configure applies the runtime config, replacing the base image's entrypoint
+// and command and overriding its environment variables by name
+func (img *Image) Configure(rc RuntimeConfig) {
+	config, _ := img.Config["config"].(map[string]interface{})
+	if config == nil {
+		config = map[string]interface{}{}
+		img.Config["config"] = config
+	}
+
+	if rc.WorkingDir != "" {
+		config["WorkingDir"] = rc.WorkingDir
+	}
+	if rc.Entrypoint != nil {
+		config["Entrypoint"] = rc.Entrypoint
+	}
+	if rc.Cmd != nil {
+		config["Cmd"] = rc.Cmd
+	}
+
+	env := []interface{}{}
+	if existing, ok := config["Env"].([]interface{}); ok {
+		for _, entry := range existing {
+			name, _, _ := strings.Cut(fmt.Sprint(entry), "=")
+			if _, overridden := rc.Env[name]; !overridden {
+				env = append(env, entry)
+			}
+		}
+	}
+	for _, name := range sortedKeys(rc.Env) {
+		env = append(env, name+"="+rc.Env[name])
+	}
+	config["Env"] = env
+}
+
+// Architecture returns the base image's CPU architecture, e.g. amd64
+func (img *Image) Architecture() string {
+	if arch, ok := img.Config["architecture"].(string); ok {
+		return arch
+	}
+	return runtime.GOARCH
+}
+
+func (img *Image) diffIDs() []string {
+	rootfs, _ := img.Config["rootfs"].(map[string]interface{})
+	values, _ := rootfs["diff_ids"].([]interface{})
+
+	var diffIDs []string
+	for _, value := range values {
+		diffIDs = append(diffIDs, fmt.Sprint(value))
+	}
+	return diffIDs
+}
+
+func (img *Image) blobPath(digest string) string {
+	algorithm, hash, _ := strings.Cut(digest, ":")
+	return filepath.Join(img.dir, "blobs", algorithm, hash)
+}
+
+// configBlob serializes the config with the current layers' diff IDs
+func (img *Image) configBlob() ([]byte, error) {
+	var diffIDs []string
+	for _, layer := range img.Layers {
+		diffIDs = append(diffIDs, layer.DiffID)
+	}
+
+	img.Config["rootfs"] = map[string]interface{}{"type": "layers", "diff_ids": diffIDs}
+	return json.Marshal(img.Config)
+}
+
+// Manifest returns the serialized image manifest and its config blob
+func (img *Image) Manifest() ([]byte, []byte, error) {
+	config, err := img.configBlob()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeManifest,
+		Config:        Descriptor{MediaType: MediaTypeConfig, Digest: digestBytes(config), Size: int64(len(config))},
+	}
+	for _, layer := range img.Layers {
+		m.Layers = append(m.Layers, layer.Descriptor)
+	}
+
+	content, err := json.Marshal(m)
+	return content, config, err
+}
+
+// Digest returns the digest of the image manifest, which identifies the
+// image in a registry
+func (img *Image) Digest() (string, error) {
+	content, _, err := img.Manifest()
+	if err != nil {
+		return "", err
+	}
+	return digestBytes(content), nil
+}
+
+// ociLayerType maps Docker layer media types to their OCI equivalents
+func ociLayerType(mediaType string) string {
+	switch mediaType {
+	case "application/vnd.docker.image.rootfs.diff.tar.gzip":
+		return MediaTypeLayerGzip
+	case "application/vnd.docker.image.rootfs.diff.tar":
+		return MediaTypeLayer
+	default:
+		return mediaType
+	}
+}
+
+// extractTar extracts an image tarball into dir. Links are resolved to copies
+// of their targets once everything else is extracted, since legacy `docker
+// save` archives symlink layers shared between images to a single layer.tar
+func extractTar(path string, dir string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = bufio.NewReader(file)
+	if isGzip(path) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	root := filepath.Clean(dir)
+	inside := func(target string) bool {
+		return strings.HasPrefix(target, root+string(os.PathSeparator))
+	}
+
+	// links maps each link to the file it points at
+	links := map[string]string{}
+
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// Archives made with `tar -C dir .` start with a ./ entry
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if target == root {
+			continue
+		}
+		if !inside(target) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.Create(target)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink, tar.TypeLink:
+			// Symlinks are relative to their own directory, hard links to
+			// the root of the archive
+			linkname := filepath.FromSlash(header.Linkname)
+			var source string
+			if header.Typeflag == tar.TypeSymlink {
+				source = filepath.Join(filepath.Dir(target), linkname)
+			} else {
+				source = filepath.Join(dir, linkname)
+			}
+			if filepath.IsAbs(linkname) || !inside(source) {
+				return fmt.Errorf("invalid link in archive: %s -> %s", header.Name, header.Linkname)
+			}
+			links[target] = source
+		}
+	}
+
+	// Links can point at other links, so copy in passes until none is left
+	for len(links) > 0 {
+		copied := 0
+		for target, source := range links {
+			if _, pending := links[source]; pending {
+				continue
+			}
+			if err := copyRegularFile(source, target); err != nil {
+				rel, _ := filepath.Rel(dir, target)
+				return fmt.Errorf("failed to resolve link %s: %v", filepath.ToSlash(rel), err)
+			}
+			delete(links, target)
+			copied++
+		}
+		if copied == 0 {
+			return fmt.Errorf("archive has a link cycle")
+		}
+	}
+
+	return nil
+}
+
+// copyRegularFile copies the file at src to dest, creating its directory
+func copyRegularFile(src string, dest string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a file", filepath.Base(src))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := copyFile(out, src); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func isGzip(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false
+	}
+	return magic[0] == 0x1f && magic[1] == 0x8b
+}
+
+func readJSON(path string, value interface{}) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, value)
+}
+
+func digestFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+func digestBytes(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,447 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type tarEntry struct {
+	name     string
+	content  []byte
+	typeflag byte
+	linkname string
+}
+
+func writeTestTar(t *testing.T, path string, entries []tarEntry) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tw := tar.NewWriter(file)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Typeflag: entry.typeflag, Linkname: entry.linkname}
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(entry.content))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write(entry.content); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testLayer returns an uncompressed layer holding a single file
+func testLayer(t *testing.T, name string, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, content []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func mustJSON(t *testing.T, value interface{}) []byte {
+	t.Helper()
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+// writeLayoutBase writes a multi-platform OCI layout tarball with an arm64
+// and an amd64 image, each with one gzipped layer, and returns the amd64
+// layer's digest
+func writeLayoutBase(t *testing.T, path string) string {
+	t.Helper()
+
+	var entries []tarEntry
+	var manifests []Descriptor
+	amd64Layer := ""
+	for _, arch := range []string{"arm64", "amd64"} {
+		layer := testLayer(t, "etc/arch", arch)
+		compressed := gzipBytes(t, layer)
+		config := mustJSON(t, map[string]interface{}{
+			"architecture": arch,
+			"os":           "linux",
+			"config":       map[string]interface{}{"Env": []string{"PATH=/usr/bin", "LANG=C"}},
+			"rootfs":       map[string]interface{}{"type": "layers", "diff_ids": []string{digestBytes(layer)}},
+		})
+		m := mustJSON(t, manifest{
+			SchemaVersion: 2,
+			MediaType:     MediaTypeManifest,
+			Config:        Descriptor{MediaType: MediaTypeConfig, Digest: digestBytes(config), Size: int64(len(config))},
+			Layers:        []Descriptor{{MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", Digest: digestBytes(compressed), Size: int64(len(compressed))}},
+		})
+
+		entries = append(entries,
+			tarEntry{name: blobName(digestBytes(compressed)), content: compressed},
+			tarEntry{name: blobName(digestBytes(config)), content: config},
+			tarEntry{name: blobName(digestBytes(m)), content: m},
+		)
+		manifests = append(manifests, Descriptor{
+			MediaType: MediaTypeManifest,
+			Digest:    digestBytes(m),
+			Size:      int64(len(m)),
+			Platform:  &Platform{Architecture: arch, OS: "linux"},
+		})
+		if arch == "amd64" {
+			amd64Layer = digestBytes(compressed)
+		}
+	}
+
+	entries = append(entries,
+		tarEntry{name: "oci-layout", content: []byte(`{"imageLayoutVersion":"1.0.0"}`)},
+		tarEntry{name: "index.json", content: mustJSON(t, index{SchemaVersion: 2, MediaType: MediaTypeIndex, Manifests: manifests})},
+	)
+	writeTestTar(t, path, entries)
+
+	return amd64Layer
+}
+
+func TestLoadBaseLayout(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "base.tar")
+	layerDigest := writeLayoutBase(t, path)
+
+	img, err := LoadBase(path, "amd64", filepath.Join(dir, "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if arch := img.Architecture(); arch != "amd64" {
+		t.Errorf("architecture = %s, want amd64", arch)
+	}
+	if len(img.Layers) != 1 {
+		t.Fatalf("got %d layers, want 1", len(img.Layers))
+	}
+
+	layer := img.Layers[0]
+	if layer.Digest != layerDigest {
+		t.Errorf("layer digest = %s, want %s", layer.Digest, layerDigest)
+	}
+	if layer.MediaType != MediaTypeLayerGzip {
+		t.Errorf("layer media type = %s, want %s", layer.MediaType, MediaTypeLayerGzip)
+	}
+	if digest, _, err := digestFile(layer.Path); err != nil || digest != layerDigest {
+		t.Errorf("layer file digest = %s (%v), want %s", digest, err, layerDigest)
+	}
+}
+
+func TestLoadBaseDockerArchive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "base.tar")
+
+	layer := testLayer(t, "etc/os-release", "ID=test")
+	config := mustJSON(t, map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"rootfs":       map[string]interface{}{"type": "layers", "diff_ids": []string{digestBytes(layer), digestBytes(layer)}},
+	})
+
+	// docker save writes a layer shared by two positions once and symlinks
+	// the other to it
+	writeTestTar(t, path, []tarEntry{
+		{name: "aaa/", typeflag: tar.TypeDir},
+		{name: "aaa/layer.tar", content: layer},
+		{name: "bbb/", typeflag: tar.TypeDir},
+		{name: "bbb/layer.tar", typeflag: tar.TypeSymlink, linkname: "../aaa/layer.tar"},
+		{name: "config.json", content: config},
+		{name: "manifest.json", content: mustJSON(t, []map[string]interface{}{{
+			"Config":   "config.json",
+			"RepoTags": []string{"base:latest"},
+			"Layers":   []string{"aaa/layer.tar", "bbb/layer.tar"},
+		}})},
+	})
+
+	img, err := LoadBase(path, "amd64", filepath.Join(dir, "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(img.Layers) != 2 {
+		t.Fatalf("got %d layers, want 2", len(img.Layers))
+	}
+	for i, l := range img.Layers {
+		if l.Digest != digestBytes(layer) {
+			t.Errorf("layer %d digest = %s, want %s", i, l.Digest, digestBytes(layer))
+		}
+		if l.Size != int64(len(layer)) {
+			t.Errorf("layer %d size = %d, want %d", i, l.Size, len(layer))
+		}
+		if l.MediaType != MediaTypeLayer {
+			t.Errorf("layer %d media type = %s, want %s", i, l.MediaType, MediaTypeLayer)
+		}
+	}
+}
+
+func TestExtractTarLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "symlink chain and hard link",
+			entries: []tarEntry{
+				{name: "c/file", typeflag: tar.TypeSymlink, linkname: "../b/file"},
+				{name: "b/file", typeflag: tar.TypeSymlink, linkname: "../a/file"},
+				{name: "a/file", content: []byte("data")},
+				{name: "d/file", typeflag: tar.TypeLink, linkname: "a/file"},
+			},
+			want: map[string]string{"a/file": "data", "b/file": "data", "c/file": "data", "d/file": "data"},
+		},
+		{
+			name:    "symlink out of the archive",
+			entries: []tarEntry{{name: "a/file", typeflag: tar.TypeSymlink, linkname: "../../etc/passwd"}},
+			wantErr: "invalid link",
+		},
+		{
+			name:    "absolute symlink",
+			entries: []tarEntry{{name: "a/file", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}},
+			wantErr: "invalid link",
+		},
+		{
+			name:    "dangling symlink",
+			entries: []tarEntry{{name: "a/file", typeflag: tar.TypeSymlink, linkname: "missing"}},
+			wantErr: "failed to resolve link a/file",
+		},
+		{
+			name: "cycle",
+			entries: []tarEntry{
+				{name: "a", typeflag: tar.TypeSymlink, linkname: "b"},
+				{name: "b", typeflag: tar.TypeSymlink, linkname: "a"},
+			},
+			wantErr: "link cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "archive.tar")
+			writeTestTar(t, path, tt.entries)
+
+			out := filepath.Join(dir, "out")
+			err := extractTar(path, out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, want := range tt.want {
+				path := filepath.Join(out, filepath.FromSlash(name))
+				info, err := os.Lstat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !info.Mode().IsRegular() {
+					t.Errorf("%s is not a regular file", name)
+				}
+				if content, _ := os.ReadFile(path); string(content) != want {
+					t.Errorf("%s = %q, want %q", name, content, want)
+				}
+			}
+		})
+	}
+}
+
+func writeAppDir(t *testing.T, dir string) {
+	t.Helper()
+
+	files := map[string]string{
+		"upify_handler.py":      "print('hello')",
+		"lib/pkg/__init__.py":   "",
+		"lib/pkg/module.py":     "x = 1",
+		"requirements.txt":      "flask==3.0.0",
+		"static/css/styles.css": "body {}",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAppendLayerDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.tar")
+	writeLayoutBase(t, base)
+
+	build := func(name string, modTime time.Time) *Image {
+		app := filepath.Join(dir, name)
+		writeAppDir(t, app)
+		err := filepath.Walk(app, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return os.Chtimes(path, modTime, modTime)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := LoadBase(base, "amd64", filepath.Join(dir, name+"-work"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := img.AppendLayer(app, "/var/task"); err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	first := build("app1", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	second := build("app2", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	if len(first.Layers) != 2 || len(second.Layers) != 2 {
+		t.Fatalf("got %d and %d layers, want 2", len(first.Layers), len(second.Layers))
+	}
+
+	a, b := first.Layers[1], second.Layers[1]
+	if a.Digest != b.Digest || a.DiffID != b.DiffID || a.Size != b.Size {
+		t.Errorf("app layers differ: %+v and %+v", a.Descriptor, b.Descriptor)
+	}
+
+	firstDigest, err := first.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondDigest, err := second.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firstDigest != secondDigest {
+		t.Errorf("image digests differ: %s and %s", firstDigest, secondDigest)
+	}
+}
+
+func TestWriteTarballRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.tar")
+	writeLayoutBase(t, base)
+
+	img, err := LoadBase(base, "amd64", filepath.Join(dir, "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := filepath.Join(dir, "app")
+	writeAppDir(t, app)
+	if err := img.AppendLayer(app, "/app"); err != nil {
+		t.Fatal(err)
+	}
+	img.Configure(RuntimeConfig{
+		WorkingDir: "/app",
+		Cmd:        []string{"python", "upify_handler.py"},
+		Env:        map[string]string{"LANG": "C.UTF-8", "PORT": "8080"},
+	})
+
+	path := filepath.Join(dir, "image.tar")
+	if err := img.WriteTarball(path, "app:latest"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBase(path, "amd64", filepath.Join(dir, "loaded"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loaded.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("digest after round trip = %s, want %s", got, want)
+	}
+
+	if len(loaded.Layers) != len(img.Layers) {
+		t.Fatalf("got %d layers, want %d", len(loaded.Layers), len(img.Layers))
+	}
+	for i := range img.Layers {
+		if loaded.Layers[i].Descriptor.Digest != img.Layers[i].Descriptor.Digest || loaded.Layers[i].DiffID != img.Layers[i].DiffID {
+			t.Errorf("layer %d = %+v, want %+v", i, loaded.Layers[i], img.Layers[i])
+		}
+	}
+
+	config, _ := loaded.Config["config"].(map[string]interface{})
+	env, _ := json.Marshal(config["Env"])
+	if string(env) != `["PATH=/usr/bin","LANG=C.UTF-8","PORT=8080"]` {
+		t.Errorf("env = %s", env)
+	}
+	if config["WorkingDir"] != "/app" {
+		t.Errorf("working dir = %v, want /app", config["WorkingDir"])
+	}
+
+	// The docker manifest.json points at the same blobs
+	dockerDir := filepath.Join(dir, "docker")
+	if err := extractTar(path, dockerDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dockerDir, "index.json")); err != nil {
+		t.Fatal(err)
+	}
+	docker := &Image{dir: dockerDir}
+	if err := docker.loadDockerArchive(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := docker.Digest(); got != want {
+		t.Errorf("digest from manifest.json = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+package image
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// layerTime is the modification time of every file in the app layer, so the
+// same staging dir always produces the same layer digest
+var layerTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// AppendLayer adds a layer holding the contents of dir at root (e.g.
+// /var/task) in the image. The files are owned by root with their modes
+// preserved
+func (img *Image) AppendLayer(dir string, root string) error {
+	blobsDir := filepath.Join(img.dir, "blobs", "sha256")
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(blobsDir, "layer-")
+	if err != nil {
+		return err
+	}
+	defer tmp.Close()
+
+	compressedHash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tmp, compressedHash)}
+	gz, err := gzip.NewWriterLevel(counter, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+
+	diffHash := sha256.New()
+	tw := tar.NewWriter(io.MultiWriter(gz, diffHash))
+
+	if err := writeLayer(tw, dir, strings.Trim(root, "/")); err != nil {
+		return fmt.Errorf("failed to write layer: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	digest := "sha256:" + hex.EncodeToString(compressedHash.Sum(nil))
+	blobPath := img.blobPath(digest)
+	if err := os.Rename(tmp.Name(), blobPath); err != nil {
+		return err
+	}
+
+	img.Layers = append(img.Layers, Layer{
+		Descriptor: Descriptor{MediaType: MediaTypeLayerGzip, Digest: digest, Size: counter.n},
+		DiffID:     "sha256:" + hex.EncodeToString(diffHash.Sum(nil)),
+		Path:       blobPath,
+	})
+
+	// History is optional, but if present it has to cover every layer
+	if history, ok := img.Config["history"].([]interface{}); ok && len(history) > 0 {
+		img.Config["history"] = append(history, map[string]interface{}{
+			"created":    layerTime.Format(time.RFC3339),
+			"created_by": "upify: COPY . /" + strings.Trim(root, "/"),
+		})
+	}
+
+	return nil
+}
+
+// writeLayer writes the parent directories of root and then everything in
+// dir below it, in sorted order
+func writeLayer(tw *tar.Writer, dir string, root string) error {
+	if root != "" {
+		parts := strings.Split(root, "/")
+		for i := range parts {
+			header := &tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     strings.Join(parts[:i+1], "/") + "/",
+				Mode:     0755,
+				ModTime:  layerTime,
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+		}
+	}
+
+	var paths []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		info, err := os.Lstat(p)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := path.Join(root, filepath.ToSlash(rel))
+
+		header := &tar.Header{
+			Name:    name,
+			Mode:    int64(info.Mode().Perm()),
+			ModTime: layerTime,
+		}
+
+		switch {
+		case info.IsDir():
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = target
+		case info.Mode().IsRegular():
+			header.Typeflag = tar.TypeReg
+			header.Size = info.Size()
+		default:
+			continue
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			if err := copyFile(tw, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func copyFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
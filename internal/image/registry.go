@@ -0,0 +1,368 @@
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Reference is a parsed image reference like
+// 123456789012.dkr.ecr.us-east-1.amazonaws.com/app:latest
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+func (r Reference) String() string {
+	return r.Registry + "/" + r.Repository + ":" + r.Tag
+}
+
+// WithDigest returns the reference pinned to a manifest digest
+func (r Reference) WithDigest(digest string) string {
+	return r.Registry + "/" + r.Repository + "@" + digest
+}
+
+// ParseReference parses an image reference. References without a registry
+// host refer to Docker Hub, and the tag defaults to latest
+func ParseReference(ref string) (Reference, error) {
+	if ref == "" || strings.Contains(ref, "@") {
+		return Reference{}, fmt.Errorf("invalid image reference %q, use registry/repository[:tag]", ref)
+	}
+
+	registry := "index.docker.io"
+	repository := ref
+	if host, rest, ok := strings.Cut(ref, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		registry, repository = host, rest
+	} else if !strings.Contains(ref, "/") {
+		repository = "library/" + ref
+	}
+
+	tag := "latest"
+	if i := strings.LastIndex(repository, ":"); i >= 0 {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+
+	if repository == "" || tag == "" || repository != strings.ToLower(repository) {
+		return Reference{}, fmt.Errorf("invalid image reference %q, use registry/repository[:tag]", ref)
+	}
+
+	return Reference{Registry: registry, Repository: repository, Tag: tag}, nil
+}
+
+// Registry pushes images using the OCI distribution API. Plain HTTP is used
+// for localhost registries or when Insecure is set
+type Registry struct {
+	Insecure bool
+
+	client *http.Client
+	token  string
+	basic  string
+}
+
+// Push uploads the image's blobs that the registry doesn't have yet and tags
+// the manifest, returning its digest
+func (r *Registry) Push(img *Image, ref Reference) (string, error) {
+	if r.client == nil {
+		r.client = http.DefaultClient
+	}
+
+	manifestContent, config, err := img.Manifest()
+	if err != nil {
+		return "", err
+	}
+
+	for _, layer := range img.Layers {
+		path := layer.Path
+		open := func() (io.ReadCloser, error) { return os.Open(path) }
+		if err := r.pushBlob(ref, layer.Digest, layer.Size, open); err != nil {
+			return "", fmt.Errorf("failed to push layer %s: %v", layer.Digest, err)
+		}
+	}
+
+	configDigest := digestBytes(config)
+	open := func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(config)), nil }
+	if err := r.pushBlob(ref, configDigest, int64(len(config)), open); err != nil {
+		return "", fmt.Errorf("failed to push image config: %v", err)
+	}
+
+	resp, err := r.do(ref, http.MethodPut, r.url(ref, "/manifests/"+ref.Tag), map[string]string{"Content-Type": MediaTypeManifest},
+		func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(manifestContent)), nil }, int64(len(manifestContent)))
+	if err != nil {
+		return "", fmt.Errorf("failed to push manifest: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to push manifest: %s", responseError(resp))
+	}
+
+	return digestBytes(manifestContent), nil
+}
+
+func (r *Registry) pushBlob(ref Reference, digest string, size int64, open func() (io.ReadCloser, error)) error {
+	resp, err := r.do(ref, http.MethodHead, r.url(ref, "/blobs/"+digest), nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resp, err = r.do(ref, http.MethodPost, r.url(ref, "/blobs/uploads/"), nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to start upload: %s", responseError(resp))
+	}
+
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid upload location: %v", err)
+	}
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+
+	resp, err = r.do(ref, http.MethodPut, location.String(), map[string]string{"Content-Type": "application/octet-stream"}, open, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to upload: %s", responseError(resp))
+	}
+
+	return nil
+}
+
+func (r *Registry) url(ref Reference, path string) string {
+	scheme := "https"
+	if r.Insecure || isLocalhost(ref.Registry) {
+		scheme = "http"
+	}
+
+	host := ref.Registry
+	if host == "index.docker.io" {
+		host = "registry-1.docker.io"
+	}
+
+	return scheme + "://" + host + "/v2/" + ref.Repository + path
+}
+
+// do sends a request, authenticating and retrying once if the registry asks
+// for credentials. body is reopened for the retry
+func (r *Registry) do(ref Reference, method string, target string, headers map[string]string, body func() (io.ReadCloser, error), size int64) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		var reader io.ReadCloser
+		if body != nil {
+			var err error
+			if reader, err = body(); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := http.NewRequest(method, target, reader)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.ContentLength = size
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		switch {
+		case r.token != "":
+			req.Header.Set("Authorization", "Bearer "+r.token)
+		case r.basic != "":
+			req.Header.Set("Authorization", "Basic "+r.basic)
+		}
+
+		return r.client.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	if err := r.authenticate(ref, resp.Header.Get("WWW-Authenticate")); err != nil {
+		return nil, err
+	}
+
+	return send()
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate answers a Basic or Bearer challenge using the credentials for
+// the registry from the environment or the Docker config
+func (r *Registry) authenticate(ref Reference, challenge string) error {
+	username, password, err := credentials(ref.Registry)
+	if err != nil {
+		return err
+	}
+
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if username == "" {
+			return fmt.Errorf("%s requires credentials, run `docker login %s` or set UPIFY_REGISTRY_USERNAME and UPIFY_REGISTRY_PASSWORD", ref.Registry, ref.Registry)
+		}
+		r.basic = base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		return nil
+	case "bearer":
+		values := map[string]string{}
+		for _, match := range challengeParam.FindAllStringSubmatch(params, -1) {
+			values[match[1]] = match[2]
+		}
+
+		tokenURL, err := url.Parse(values["realm"])
+		if err != nil || values["realm"] == "" {
+			return fmt.Errorf("invalid auth challenge from %s: %s", ref.Registry, challenge)
+		}
+		query := tokenURL.Query()
+		if values["service"] != "" {
+			query.Set("service", values["service"])
+		}
+		query.Set("scope", "repository:"+ref.Repository+":pull,push")
+		tokenURL.RawQuery = query.Encode()
+
+		req, err := http.NewRequest(http.MethodGet, tokenURL.String(), nil)
+		if err != nil {
+			return err
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to get registry token: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to get registry token: %s", responseError(resp))
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return fmt.Errorf("failed to parse registry token: %v", err)
+		}
+
+		r.token = token.Token
+		if r.token == "" {
+			r.token = token.AccessToken
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported auth challenge from %s: %s", ref.Registry, challenge)
+	}
+}
+
+// credentials looks up the username and password for a registry, first in
+// UPIFY_REGISTRY_USERNAME and UPIFY_REGISTRY_PASSWORD, then in the Docker
+// config written by `docker login`, including credential helpers such as
+// docker-credential-ecr-login
+func credentials(registry string) (string, string, error) {
+	if username := os.Getenv("UPIFY_REGISTRY_USERNAME"); username != "" {
+		return username, os.Getenv("UPIFY_REGISTRY_PASSWORD"), nil
+	}
+
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", nil
+		}
+		configDir = filepath.Join(home, ".docker")
+	}
+
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+		CredHelpers map[string]string `json:"credHelpers"`
+		CredsStore  string            `json:"credsStore"`
+	}
+	if err := readJSON(filepath.Join(configDir, "config.json"), &dockerConfig); err != nil {
+		return "", "", nil
+	}
+
+	helper := dockerConfig.CredHelpers[registry]
+	if helper == "" {
+		helper = dockerConfig.CredsStore
+	}
+	if helper != "" {
+		return credentialHelper(helper, registry)
+	}
+
+	for host, entry := range dockerConfig.Auths {
+		if host != registry && !strings.Contains(host, "://"+registry) {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid auth for %s in %s: %v", host, filepath.Join(configDir, "config.json"), err)
+		}
+		username, password, _ := strings.Cut(string(decoded), ":")
+		return username, password, nil
+	}
+
+	return "", "", nil
+}
+
+func credentialHelper(helper string, registry string) (string, string, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	output, err := cmd.Output()
+	if err != nil {
+		// Helpers exit with an error when they have no credentials for the
+		// registry, which anonymous pushes to local registries don't need
+		return "", "", nil
+	}
+
+	var creds struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(output, &creds); err != nil {
+		return "", "", fmt.Errorf("failed to parse docker-credential-%s output: %v", helper, err)
+	}
+
+	return creds.Username, creds.Secret, nil
+}
+
+func isLocalhost(registry string) bool {
+	host := registry
+	if h, _, ok := strings.Cut(registry, ":"); ok && !strings.HasPrefix(registry, "[") {
+		host = h
+	}
+	return host == "localhost" || host == "127.0.0.1" || strings.HasPrefix(registry, "[::1]")
+}
+
+func responseError(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		return resp.Status
+	}
+	return resp.Status + ": " + message
+}
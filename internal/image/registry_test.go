@@ -0,0 +1,167 @@
+package image
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testRegistry implements the blob and manifest endpoints of the OCI
+// distribution API in memory
+type testRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/team/app")
+	switch {
+	case req.Method == http.MethodHead && strings.HasPrefix(path, "/blobs/sha256:"):
+		if _, ok := r.blobs[strings.TrimPrefix(path, "/blobs/")]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case req.Method == http.MethodPost && path == "/blobs/uploads/":
+		r.uploads++
+		w.Header().Set("Location", fmt.Sprintf("/v2/team/app/blobs/uploads/%d?state=abc", r.uploads))
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodPut && strings.HasPrefix(path, "/blobs/uploads/"):
+		if req.URL.Query().Get("state") != "abc" {
+			http.Error(w, "upload state lost", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		digest := req.URL.Query().Get("digest")
+		if digestBytes(body) != digest {
+			http.Error(w, "digest mismatch", http.StatusBadRequest)
+			return
+		}
+		r.blobs[digest] = body
+		w.WriteHeader(http.StatusCreated)
+	case req.Method == http.MethodPut && strings.HasPrefix(path, "/manifests/"):
+		if req.Header.Get("Content-Type") != MediaTypeManifest {
+			http.Error(w, "unexpected content type", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		r.manifests[strings.TrimPrefix(path, "/manifests/")] = body
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.Error(w, "unexpected request "+req.Method+" "+req.URL.Path, http.StatusNotFound)
+	}
+}
+
+func TestRegistryPush(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.tar")
+	writeLayoutBase(t, base)
+
+	img, err := LoadBase(base, "amd64", filepath.Join(dir, "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := filepath.Join(dir, "app")
+	writeAppDir(t, app)
+	if err := img.AppendLayer(app, "/app"); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := newTestRegistry()
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	ref, err := ParseReference(strings.TrimPrefix(server.URL, "http://") + "/team/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Registry{Insecure: true}
+	digest, err := r.Push(img, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != want {
+		t.Errorf("pushed digest = %s, want %s", digest, want)
+	}
+
+	manifestContent, config, err := img.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(registry.manifests["v1"]) != string(manifestContent) {
+		t.Errorf("registry has manifest %s, want %s", registry.manifests["v1"], manifestContent)
+	}
+	if string(registry.blobs[digestBytes(config)]) != string(config) {
+		t.Errorf("registry is missing the config blob")
+	}
+	for _, layer := range img.Layers {
+		if int64(len(registry.blobs[layer.Digest])) != layer.Size {
+			t.Errorf("registry is missing layer %s", layer.Digest)
+		}
+	}
+	if registry.uploads != len(img.Layers)+1 {
+		t.Errorf("got %d uploads, want %d", registry.uploads, len(img.Layers)+1)
+	}
+
+	// Blobs the registry already has aren't uploaded again
+	if _, err := r.Push(img, ref); err != nil {
+		t.Fatal(err)
+	}
+	if registry.uploads != len(img.Layers)+1 {
+		t.Errorf("got %d uploads after pushing again, want %d", registry.uploads, len(img.Layers)+1)
+	}
+}
+
+func TestRegistryPushError(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.tar")
+	writeLayoutBase(t, base)
+
+	img, err := LoadBase(base, "amd64", filepath.Join(dir, "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.Error(w, "quota exceeded", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	ref, err := ParseReference(strings.TrimPrefix(server.URL, "http://") + "/team/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = (&Registry{Insecure: true}).Push(img, ref)
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("err = %v, want the registry's error", err)
+	}
+}
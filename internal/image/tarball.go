@@ -0,0 +1,113 @@
+package image
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+)
+
+// WriteTarball writes the image as an OCI image layout tarball tagged with
+// ref. A docker manifest.json is included too, so the same file can be loaded
+// with `docker load` or copied to a registry with skopeo or crane
+func (img *Image) WriteTarball(path string, ref string) error {
+	manifestContent, config, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+	manifestDigest := digestBytes(manifestContent)
+	configDigest := digestBytes(config)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tw := tar.NewWriter(file)
+
+	layout, _ := json.Marshal(map[string]string{"imageLayoutVersion": "1.0.0"})
+	idx, _ := json.Marshal(index{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeIndex,
+		Manifests: []Descriptor{{
+			MediaType:   MediaTypeManifest,
+			Digest:      manifestDigest,
+			Size:        int64(len(manifestContent)),
+			Annotations: map[string]string{"org.opencontainers.image.ref.name": ref},
+		}},
+	})
+
+	var layerPaths []string
+	for _, layer := range img.Layers {
+		layerPaths = append(layerPaths, blobName(layer.Digest))
+	}
+	dockerManifest, _ := json.Marshal([]map[string]interface{}{{
+		"Config":   blobName(configDigest),
+		"RepoTags": []string{ref},
+		"Layers":   layerPaths,
+	}})
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"oci-layout", layout},
+		{"index.json", idx},
+		{"manifest.json", dockerManifest},
+		{blobName(manifestDigest), manifestContent},
+		{blobName(configDigest), config},
+	}
+
+	for _, dir := range []string{"blobs/", "blobs/sha256/"} {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir, Mode: 0755, ModTime: layerTime}); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range files {
+		header := &tar.Header{Typeflag: tar.TypeReg, Name: f.name, Mode: 0644, Size: int64(len(f.content)), ModTime: layerTime}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.content); err != nil {
+			return err
+		}
+	}
+
+	written := map[string]bool{}
+	for _, layer := range img.Layers {
+		if written[layer.Digest] {
+			continue
+		}
+		written[layer.Digest] = true
+
+		header := &tar.Header{Typeflag: tar.TypeReg, Name: blobName(layer.Digest), Mode: 0644, Size: layer.Size, ModTime: layerTime}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := copyFile(tw, layer.Path); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func blobName(digest string) string {
+	algorithm, hash, _ := strings.Cut(digest, ":")
+	return "blobs/" + algorithm + "/" + hash
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
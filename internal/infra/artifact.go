@@ -0,0 +1,216 @@
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/codeupify/upify/internal/sbom"
+)
+
+// CreateArtifact checks the licenses of the packages in the staging directory,
+// zips it into zipPath, verifies that both the unzipped and zipped sizes fit
+// within the platform's limits, and writes an SBOM of the packages next to it
+func CreateArtifact(cfg *config.Config, p platform.Platform, stagingDir string, zipPath string) error {
+	components, err := checkPackages(cfg, stagingDir)
+	if err != nil {
+		return err
+	}
+
+	unzippedSize, err := fs.DirSize(stagingDir)
+	if err != nil {
+		return fmt.Errorf("failed to measure staging directory: %v", err)
+	}
+
+	fmt.Printf("Creating %s...\n", zipPath)
+	if err := fs.CreateZip(stagingDir, zipPath); err != nil {
+		return fmt.Errorf("failed to create zip: %v", err)
+	}
+
+	info, err := os.Stat(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat zip: %v", err)
+	}
+
+	if err := CheckArtifactSize(p, unzippedSize, info.Size()); err != nil {
+		return err
+	}
+
+	return WriteSBOM(cfg, p, components, zipPath)
+}
+
+// checkPackages lists the packages in the staging dir and applies the
+// license policy and audit gate to them
+func checkPackages(cfg *config.Config, stagingDir string) ([]sbom.Component, error) {
+	// Package managers link local and workspace dependencies into
+	// node_modules, which would point nowhere once deployed
+	if err := fs.ResolveSymlinks(stagingDir); err != nil {
+		return nil, fmt.Errorf("failed to resolve symlinks: %v", err)
+	}
+
+	components, err := sbom.Scan(stagingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan packages: %v", err)
+	}
+
+	if err := CheckLicenses(cfg, components); err != nil {
+		return nil, err
+	}
+
+	if err := CheckVulnerabilities(cfg, components); err != nil {
+		return nil, err
+	}
+
+	return components, nil
+}
+
+func CheckArtifactSize(p platform.Platform, unzippedSize int64, zippedSize int64) error {
+	limits, ok := platform.Limits[p]
+	if !ok {
+		return nil
+	}
+
+	fmt.Printf("Artifact size: %s zipped, %s unzipped\n", fs.FormatSize(zippedSize), fs.FormatSize(unzippedSize))
+
+	if limits.Unzipped > 0 && unzippedSize > limits.Unzipped {
+		return fmt.Errorf("unzipped artifact is %s, which exceeds the %s limit for %s; run `upify package %s --analyze` to find what to trim",
+			fs.FormatSize(unzippedSize), fs.FormatSize(limits.Unzipped), p, p)
+	}
+
+	if limits.Zipped > 0 && zippedSize > limits.Zipped {
+		return fmt.Errorf("zipped artifact is %s, which exceeds the %s limit for %s; run `upify package %s --analyze` to find what to trim",
+			fs.FormatSize(zippedSize), fs.FormatSize(limits.Zipped), p, p)
+	}
+
+	return nil
+}
+
+type Manifest struct {
+	Name         string    `json:"name"`
+	Platform     string    `json:"platform"`
+	Language     string    `json:"language"`
+	Runtime      string    `json:"runtime,omitempty"`
+	SHA256       string    `json:"sha256"`
+	Size         int64     `json:"size"`
+	UnzippedSize int64     `json:"unzipped_size"`
+	UpifyVersion string    `json:"upify_version,omitempty"`
+	SBOM         string    `json:"sbom,omitempty"`
+	SBOMSHA256   string    `json:"sbom_sha256,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Files        []string  `json:"files"`
+}
+
+// ManifestPath returns the manifest location for an artifact, e.g.
+// dist/app.zip -> dist/app.manifest.json
+func ManifestPath(zipPath string) string {
+	return strings.TrimSuffix(zipPath, filepath.Ext(zipPath)) + ".manifest.json"
+}
+
+func BuildManifest(cfg *config.Config, p platform.Platform, stagingDir string, zipPath string) (*Manifest, error) {
+	hash, err := fs.FileSHA256(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash artifact: %v", err)
+	}
+
+	info, err := os.Stat(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat artifact: %v", err)
+	}
+
+	unzippedSize, err := fs.DirSize(stagingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure staging directory: %v", err)
+	}
+
+	files, err := fs.ListFiles(stagingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged files: %v", err)
+	}
+
+	sbomPath, sbomHash, err := FindSBOM(cfg, zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manifest{
+		Name:         cfg.Name,
+		Platform:     string(p),
+		Language:     string(cfg.Language),
+		Runtime:      GetPlatformRuntime(p),
+		SHA256:       hash,
+		Size:         info.Size(),
+		UnzippedSize: unzippedSize,
+		SBOM:         sbomPath,
+		SBOMSHA256:   sbomHash,
+		CreatedAt:    time.Now().UTC(),
+		Files:        files,
+	}, nil
+}
+
+func WriteManifest(manifest *Manifest, path string) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Writing %s...\n", path)
+	return os.WriteFile(path, data, 0644)
+}
+
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return &manifest, nil
+}
+
+// VerifyArtifact checks a prebuilt artifact against the manifest written next
+// to it by `upify package`, making sure it was built for the platform being
+// deployed and that its contents haven't changed since
+func VerifyArtifact(p platform.Platform, zipPath string) error {
+	if _, err := os.Stat(zipPath); err != nil {
+		return fmt.Errorf("artifact not found: %s", zipPath)
+	}
+
+	manifestPath := ManifestPath(zipPath)
+	manifest, err := ReadManifest(manifestPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no manifest found at %s; build the artifact with `upify package`, or pass --no-verify to deploy it unverified", manifestPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	if manifest.Platform != string(p) {
+		return fmt.Errorf("artifact %s was built for %s, not %s", zipPath, manifest.Platform, p)
+	}
+
+	hash, err := fs.FileSHA256(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to hash artifact: %v", err)
+	}
+
+	if hash != manifest.SHA256 {
+		return fmt.Errorf("artifact %s does not match the sha256 recorded in %s", zipPath, manifestPath)
+	}
+
+	if runtime := GetPlatformRuntime(p); manifest.Runtime != "" && runtime != "" && runtime != manifest.Runtime {
+		return fmt.Errorf("artifact %s was built for runtime %s, but %s is configured with %s", zipPath, manifest.Runtime, p, runtime)
+	}
+
+	fmt.Printf("Verified artifact %s (sha256 %s)\n", zipPath, hash)
+	return nil
+}
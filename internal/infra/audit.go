@@ -0,0 +1,93 @@
+package infra
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codeupify/upify/internal/audit"
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/sbom"
+)
+
+// CheckVulnerabilities is the pre-deploy audit gate, run on every artifact
+// when audit.gate is set in the config. It fails when there are packages but
+// none of them has an exact version to audit
+func CheckVulnerabilities(cfg *config.Config, components []sbom.Component) error {
+	if cfg.Audit == nil || !cfg.Audit.Gate {
+		return nil
+	}
+
+	if len(components) > 0 && len(audit.Unpinned(components)) == len(components) {
+		return fmt.Errorf("audit failed: none of the %d packages has an exact version to audit; commit a lockfile or pin the versions in requirements.txt", len(components))
+	}
+
+	return AuditComponents(cfg, components, "", "")
+}
+
+// AuditComponents matches the packages against the local OSV database and
+// prints the advisories found. It fails if any is at or above the severity
+// threshold. Empty database and severity fall back to the config
+func AuditComponents(cfg *config.Config, components []sbom.Component, database string, severity string) error {
+	var ignore []string
+	if cfg.Audit != nil {
+		if database == "" {
+			database = cfg.Audit.Database
+		}
+		if severity == "" {
+			severity = cfg.Audit.Severity
+		}
+		ignore = cfg.Audit.Ignore
+	}
+
+	if database == "" {
+		return fmt.Errorf("no advisory database configured; set audit.database in .upify/config.yaml or pass --database")
+	}
+	if severity == "" {
+		severity = audit.High
+	}
+
+	threshold, err := audit.ParseSeverity(severity)
+	if err != nil {
+		return err
+	}
+
+	db, err := audit.LoadDatabase(database, audit.WantedPackages(components))
+	if err != nil {
+		return fmt.Errorf("failed to load advisory database: %v", err)
+	}
+
+	audited := len(components)
+	if unpinned := audit.Unpinned(components); len(unpinned) > 0 {
+		audited -= len(unpinned)
+		names := make([]string, len(unpinned))
+		for i, component := range unpinned {
+			names[i] = component.Name
+		}
+		fmt.Printf("Warning: skipping %d packages without an exact version: %s\n", len(unpinned), strings.Join(names, ", "))
+	}
+
+	findings := audit.Audit(db, components, ignore)
+	if len(findings) == 0 {
+		fmt.Printf("Audit passed: no known vulnerabilities in %d packages (%d advisories checked)\n", audited, db.Count)
+		return nil
+	}
+
+	fmt.Printf("\nFound %d vulnerabilities (%s):\n\n", len(findings), audit.CountBySeverity(findings))
+	audit.PrintFindings(os.Stdout, findings)
+	fmt.Println()
+
+	blocking := 0
+	for _, finding := range findings {
+		if audit.AtLeast(finding.Severity, threshold) {
+			blocking++
+		}
+	}
+
+	if blocking > 0 {
+		return fmt.Errorf("audit failed: %d vulnerabilities at or above %s; upgrade these packages or add the advisories to audit.ignore in .upify/config.yaml", blocking, threshold)
+	}
+
+	fmt.Printf("No vulnerabilities at or above %s\n", threshold)
+	return nil
+}
@@ -12,22 +12,49 @@ import (
 )
 
 func PreDeployValidate(cfg *config.Config, platform platform.Platform) error {
+	if err := ValidateTerraformDir(platform); err != nil {
+		return err
+	}
+
+	return ValidateHandler(cfg)
+}
+
+// ValidateHandler checks that the project has its upify_handler
+func ValidateHandler(cfg *config.Config) error {
+	handlerPath := GetHandlerPath(cfg)
+	if _, err := os.Stat(handlerPath); os.IsNotExist(err) {
+		if cfg.GetSourceDir() == "." {
+			return fmt.Errorf("%s not found in current working directory", GetHandlerFileName(cfg.Language))
+		}
+		return fmt.Errorf("%s not found in %s", GetHandlerFileName(cfg.Language), cfg.GetSourceDir())
+	}
+
+	return nil
+}
+
+func ValidateTerraformDir(platform platform.Platform) error {
 	terraformDir := GetPlatformTerraformDir(platform)
 	_, err := os.Stat(filepath.Join(terraformDir, "main.tf"))
 	if os.IsNotExist(err) {
 		return fmt.Errorf("couldn't find %s/main.tf, did you run `upify platform add %s`?", terraformDir, platform)
 	}
 
-	handlerPath := GetHandlerPath(cfg.Language)
-	if _, err := os.Stat(handlerPath); os.IsNotExist(err) {
-		return fmt.Errorf("%s not found in current working directory", GetHandlerFileName(cfg.Language))
+	return nil
+}
+
+// ValidatePlatformDir checks that the platform was added, whether or not it
+// is deployed with terraform
+func ValidatePlatformDir(platform platform.Platform) error {
+	platformDir := GetPlatformTerraformDir(platform)
+	if _, err := os.Stat(platformDir); os.IsNotExist(err) {
+		return fmt.Errorf("couldn't find %s, did you run `upify platform add %s`?", platformDir, platform)
 	}
 
 	return nil
 }
 
 func WriteEnvironmentVariables(platform platform.Platform) error {
-	envVars, err := loadEnvironmentVariables()
+	envVars, err := LoadEnvironmentVariables()
 	if err != nil {
 		return err
 	}
@@ -48,11 +75,11 @@ func WriteEnvironmentVariables(platform platform.Platform) error {
 	return nil
 }
 
-// loadEnvironmentVariables attempts to load environment variables from a file.
+// LoadEnvironmentVariables attempts to load environment variables from a file.
 // It first checks for a ".env.prod" file in the ".upify" directory of the current working directory.
 // If ".env.prod" does not exist, it falls back to loading ".env".
 // If neither file exists, it returns an empty map without an error.
-func loadEnvironmentVariables() (map[string]string, error) {
+func LoadEnvironmentVariables() (map[string]string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current working directory: %v", err)
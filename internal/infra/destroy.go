@@ -0,0 +1,84 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// Destroy tears down everything the platform's terraform configuration
+// created. Terraform still evaluates the configuration, so vars fills in the
+// variables deploys set, and each of fileVars points at an empty placeholder
+// file for the variables that name an artifact
+func Destroy(p platform.Platform, vars map[string]string, fileVars ...string) error {
+	if err := ValidateTerraformDir(p); err != nil {
+		return err
+	}
+
+	if err := WriteEnvironmentVariables(p); err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "upify_destroy_")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	placeholder := filepath.Join(tempDir, "placeholder")
+	if err := os.WriteFile(placeholder, nil, 0644); err != nil {
+		return fmt.Errorf("failed to write placeholder file: %v", err)
+	}
+
+	allVars := map[string]string{}
+	for key, value := range vars {
+		allVars[key] = value
+	}
+	for _, key := range fileVars {
+		allVars[key] = placeholder
+	}
+
+	terraformManager, err := NewTerraformManager(GetPlatformTerraformDir(p))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	return terraformManager.Destroy(context.Background(), allVars)
+}
+
+// PrintOutputs prints the named terraform outputs of the platform that have
+// a value, e.g. the URL of the deployed app
+func PrintOutputs(p platform.Platform, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	terraformManager, err := NewTerraformManager(GetPlatformTerraformDir(p))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	outputs, err := terraformManager.Output(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to read terraform outputs: %v", err)
+	}
+
+	for _, name := range names {
+		output, ok := outputs[name]
+		if !ok {
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(output.Value, &value); err != nil || value == nil || value == "" {
+			continue
+		}
+		fmt.Printf("%s: %v\n", name, value)
+	}
+
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/codeupify/upify/internal/lang"
 	"github.com/codeupify/upify/internal/lang/node"
 	"github.com/codeupify/upify/internal/lang/python"
+	"github.com/codeupify/upify/internal/platform"
 )
 
 const pythonHandlerCode = `import os
@@ -20,12 +21,95 @@ handler = None
 
 const nodeHandlerCode = `const app = require('./{ENTRYPOINT}');`
 
+const typescriptHandlerCode = `// Report stack traces against the original .ts sources
+(process as any).setSourceMapsEnabled?.(true);
+
+type Handler = (...args: any[]) => unknown;
+
+const entrypoint = require('./{ENTRYPOINT}');
+const app = entrypoint.default ?? entrypoint;
+
+export let handler: Handler | undefined = undefined;`
+
+// The handler sections of the platforms that run upify_handler as a server on
+// $PORT. gunicorn serves the Python app once deployed, the section only runs
+// it without gunicorn
+const serverPythonCode = `if os.getenv("UPIFY_DEPLOY_PLATFORM") == "{PLATFORM}" and __name__ == "__main__":
+    app.run(host="0.0.0.0", port=int(os.getenv("PORT", "8080")))`
+
+const serverNodeCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === '{PLATFORM}' && require.main === module) {
+    let expressApp = {APP_VAR};
+    if ({APP_VAR} && {APP_VAR}['app']) {
+        expressApp = {APP_VAR}['app'];
+    }
+    const port = parseInt(process.env.PORT || '8080', 10);
+    expressApp.listen(port, () => console.log('Listening on port ' + port));
+}`
+
+// ServerHandlerCode returns the handler section that starts the app's own
+// server on platform p
+func ServerHandlerCode(p platform.Platform, language lang.Language) (string, error) {
+	switch language {
+	case lang.Python:
+		return strings.Replace(serverPythonCode, "{PLATFORM}", string(p), -1), nil
+	case lang.JavaScript, lang.TypeScript:
+		return strings.Replace(serverNodeCode, "{PLATFORM}", string(p), -1), nil
+	default:
+		return "", fmt.Errorf("unsupported language: %s", language)
+	}
+}
+
+// ServerCommand returns the command that serves the staged project on $PORT:
+// gunicorn for Python, taking its port from PORT and its worker count from
+// WEB_CONCURRENCY, and node with the handler for JavaScript and TypeScript.
+// gunicorn runs as a module, since packages installed into the app directory
+// have no scripts on the PATH
+func ServerCommand(cfg *config.Config) []string {
+	if cfg.Language == lang.Python {
+		return []string{"python3", "-m", "gunicorn", "--access-logfile", "-", "upify_handler:app"}
+	}
+
+	handler := "upify_handler.js"
+	if cfg.Language == lang.TypeScript && !cfg.BundleEnabled() {
+		// Staging fails first if tsconfig.json can't be parsed
+		if compiled, err := node.CompiledHandler(cfg.GetSourceDir()); err == nil {
+			handler = compiled
+		}
+	}
+
+	return []string{"node", handler}
+}
+
+// ResolveHandler returns the configuration to build and deploy the project
+// with. TypeScript projects set up before typed handlers were generated only
+// have an upify_handler.js, which is deployed as JavaScript, as it was then,
+// until it's replaced by an upify_handler.ts. cfg itself is left as written
+func ResolveHandler(cfg *config.Config) *config.Config {
+	if cfg.Language != lang.TypeScript {
+		return cfg
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.GetSourceDir(), "upify_handler.ts")); err == nil {
+		return cfg
+	}
+	if _, err := os.Stat(filepath.Join(cfg.GetSourceDir(), "upify_handler.js")); err != nil {
+		return cfg
+	}
+
+	fmt.Println("upify_handler.ts not found, using upify_handler.js as JavaScript. See https://codeupify.github.io/upify/wrappers#typescript to switch to a typed handler")
+	resolved := *cfg
+	resolved.Language = lang.JavaScript
+	return &resolved
+}
+
 func GetHandlerFileName(language lang.Language) string {
 	switch language {
 	case lang.Python:
 		return "upify_handler.py"
-	case lang.JavaScript, lang.TypeScript:
+	case lang.JavaScript:
 		return "upify_handler.js"
+	case lang.TypeScript:
+		return "upify_handler.ts"
 	default:
 		return ""
 	}
@@ -35,19 +119,21 @@ func GetMainFileName(language lang.Language) string {
 	switch language {
 	case lang.Python:
 		return "upify_main.py"
-	case lang.JavaScript, lang.TypeScript:
+	case lang.JavaScript:
 		return "upify_main.js"
+	case lang.TypeScript:
+		return "upify_main.ts"
 	default:
 		return ""
 	}
 }
 
-func GetHandlerPath(language lang.Language) string {
-	return filepath.Join(".", GetHandlerFileName(language))
+func GetHandlerPath(cfg *config.Config) string {
+	return filepath.Join(cfg.GetSourceDir(), GetHandlerFileName(cfg.Language))
 }
 
-func GetMainPath(language lang.Language) string {
-	return filepath.Join(".", GetMainFileName(language))
+func GetMainPath(cfg *config.Config) string {
+	return filepath.Join(cfg.GetSourceDir(), GetMainFileName(cfg.Language))
 }
 
 func AddHandlerSection(handlerPath string, sectionName string, sectionContent string) error {
@@ -83,7 +169,7 @@ func AddPlatformHandler(cfg *config.Config, platform string, handlerCode string)
 		return fmt.Errorf("app variable is not specified in the configuration")
 	}
 
-	targetPath := GetHandlerPath(cfg.Language)
+	targetPath := GetHandlerPath(cfg)
 	if _, err := os.Stat(targetPath); os.IsNotExist(err) {
 		return fmt.Errorf("upify_handler file does not exist at %s", targetPath)
 	}
@@ -108,13 +194,15 @@ func AddHandlerFile(cfg *config.Config) error {
 	switch cfg.Language {
 	case lang.Python:
 		handlerCode = pythonHandlerCode
-	case lang.JavaScript, lang.TypeScript:
+	case lang.JavaScript:
 		handlerCode = nodeHandlerCode
+	case lang.TypeScript:
+		handlerCode = typescriptHandlerCode
 	default:
 		return fmt.Errorf("unsupported language: %s", cfg.Language)
 	}
 
-	targetPath := GetHandlerPath(cfg.Language)
+	targetPath := GetHandlerPath(cfg)
 	if _, err := os.Stat(targetPath); err == nil {
 		fmt.Printf("Handler file already exists at %s\n", targetPath)
 		return nil
@@ -146,13 +234,15 @@ func AddMainFile(cfg *config.Config) error {
 	switch cfg.Language {
 	case lang.Python:
 		mainCode = python.PythonMainTemplate
-	case lang.JavaScript, lang.TypeScript:
+	case lang.JavaScript:
 		mainCode = node.NodeMainTemplate
+	case lang.TypeScript:
+		mainCode = node.TypeScriptMainTemplate
 	default:
 		return fmt.Errorf("unsupported language: %s", cfg.Language)
 	}
 
-	mainPath := GetMainPath(cfg.Language)
+	mainPath := GetMainPath(cfg)
 	if _, err := os.Stat(mainPath); err == nil {
 		fmt.Printf("Main file already exists at %s\n", mainPath)
 	} else {
@@ -0,0 +1,45 @@
+package infra
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/lang"
+)
+
+func TestResolveHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		language lang.Language
+		files    []string
+		want     lang.Language
+	}{
+		{name: "typed handler", language: lang.TypeScript, files: []string{"upify_handler.ts"}, want: lang.TypeScript},
+		{name: "both handlers", language: lang.TypeScript, files: []string{"upify_handler.ts", "upify_handler.js"}, want: lang.TypeScript},
+		{name: "legacy handler", language: lang.TypeScript, files: []string{"upify_handler.js"}, want: lang.JavaScript},
+		{name: "no handler", language: lang.TypeScript, want: lang.TypeScript},
+		{name: "javascript", language: lang.JavaScript, files: []string{"upify_handler.js"}, want: lang.JavaScript},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg := &config.Config{Language: tt.language, SourceDir: dir}
+			resolved := ResolveHandler(cfg)
+			if resolved.Language != tt.want {
+				t.Errorf("language = %s, want %s", resolved.Language, tt.want)
+			}
+			if cfg.Language != tt.language {
+				t.Errorf("cfg.Language changed to %s", cfg.Language)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package infra
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/image"
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// imageArchitecture is the architecture picked from multi-platform base
+// images, matching the x86_64 default of Lambda and Cloud Run
+const imageArchitecture = "amd64"
+
+// ValidatePackageType checks the package_type setting and, for images, that
+// a base image is configured
+func ValidatePackageType(cfg *config.Config) error {
+	switch cfg.PackageType {
+	case "", "zip":
+		return nil
+	case "image":
+		if cfg.Image == nil || cfg.Image.Base == "" {
+			return fmt.Errorf("package_type is image but image.base is not set in .upify/config.yaml")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported package_type: %s (use zip or image)", cfg.PackageType)
+	}
+}
+
+// ImageTag returns the reference an image is tagged with: the configured
+// repository, or the project name for local tarballs
+func ImageTag(cfg *config.Config) string {
+	if cfg.Image != nil && cfg.Image.Repository != "" {
+		return cfg.Image.Repository
+	}
+	return cfg.Name + ":latest"
+}
+
+// CreateImage is the image counterpart of CreateArtifact. It checks the
+// packages in the staging directory, layers it on the base image and writes
+// the image to tarPath as an OCI tarball, with an SBOM next to it. The
+// image's blobs are kept in workDir
+func CreateImage(cfg *config.Config, p platform.Platform, stagingDir string, rc image.RuntimeConfig, workDir string, tarPath string) (*image.Image, error) {
+	if err := ValidatePackageType(cfg); err != nil {
+		return nil, err
+	}
+
+	components, err := checkPackages(cfg, stagingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Loading base image %s...\n", cfg.Image.Base)
+	img, err := image.LoadBase(cfg.Image.Base, imageArchitecture, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Adding %s layer...\n", rc.AppDir)
+	if err := img.AppendLayer(stagingDir, rc.AppDir); err != nil {
+		return nil, err
+	}
+	img.Configure(rc)
+
+	fmt.Printf("Creating %s...\n", tarPath)
+	if err := img.WriteTarball(tarPath, ImageTag(cfg)); err != nil {
+		return nil, fmt.Errorf("failed to write image: %v", err)
+	}
+
+	info, err := os.Stat(tarPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat image: %v", err)
+	}
+	fmt.Printf("Image size: %s (%d layers)\n", fs.FormatSize(info.Size()), len(img.Layers))
+
+	return img, WriteSBOM(cfg, p, components, tarPath)
+}
+
+// PushImage pushes the image to the configured repository and returns a
+// reference pinned to its digest, so deploys always pick up the new image
+func PushImage(cfg *config.Config, img *image.Image) (string, error) {
+	if cfg.Image == nil || cfg.Image.Repository == "" {
+		return "", fmt.Errorf("image.repository is not set in .upify/config.yaml")
+	}
+
+	ref, err := image.ParseReference(cfg.Image.Repository)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Pushing %s...\n", ref)
+	registry := &image.Registry{Insecure: cfg.Image.Insecure}
+	digest, err := registry.Push(img, ref)
+	if err != nil {
+		return "", err
+	}
+
+	pinned := ref.WithDigest(digest)
+	fmt.Printf("Pushed %s\n", pinned)
+	return pinned, nil
+}
+
+// ValidateImageTemplate checks that the platform's terraform accepts an
+// image_uri, which configurations created before image support don't
+func ValidateImageTemplate(p platform.Platform) error {
+	content, err := os.ReadFile(filepath.Join(GetPlatformTerraformDir(p), "main.tf"))
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(string(content), `variable "image_uri"`) {
+		return fmt.Errorf("%s/main.tf has no image_uri variable; remove .upify/environments/prod/%s and .upify/modules/%s and run `upify platform add %s` again to deploy images",
+			GetPlatformTerraformDir(p), p, p, p)
+	}
+
+	return nil
+}
@@ -1,11 +1,31 @@
 package infra
 
 import (
+	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/codeupify/upify/internal/platform"
 )
 
+var runtimePattern = regexp.MustCompile(`(?m)^\s*runtime\s*=\s*"([^"]+)"`)
+
 func GetPlatformTerraformDir(platform platform.Platform) string {
 	return filepath.Join(".upify", "environments", "prod", string(platform))
 }
+
+// GetPlatformRuntime reads the runtime that `upify platform add` wrote into the
+// platform's main.tf, returning an empty string if it can't be found
+func GetPlatformRuntime(platform platform.Platform) string {
+	content, err := os.ReadFile(filepath.Join(GetPlatformTerraformDir(platform), "main.tf"))
+	if err != nil {
+		return ""
+	}
+
+	match := runtimePattern.FindSubmatch(content)
+	if match == nil {
+		return ""
+	}
+
+	return string(match[1])
+}
@@ -0,0 +1,116 @@
+package infra
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/lang/node"
+	"github.com/codeupify/upify/internal/lang/python"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/codeupify/upify/internal/platform/adapters"
+)
+
+// InstallRequirements installs the project's dependencies and platform p's
+// adapters into dir, next to the handler. Node.js projects are then built and
+// either bundled or pruned of their devDependencies. Offline builds need the
+// adapters among the project's own dependencies
+func InstallRequirements(cfg *config.Config, p platform.Platform, dir string) error {
+	runtime := GetPlatformRuntime(p)
+
+	switch cfg.Language {
+	case lang.Python:
+		py, err := python.FindInterpreter(cfg.GetSourceDir(), cfg.PythonInterpreter())
+		if err != nil {
+			return err
+		}
+
+		if err := py.CheckRuntime(runtime); err != nil {
+			return err
+		}
+
+		if err := py.CheckPip(); err != nil {
+			return err
+		}
+
+		err = python.InstallRequirements(py, dir, cfg.GetSourceDir(), cfg.PackageManager, cfg.Offline)
+		if err != nil {
+			return err
+		}
+
+		var results []adapters.Result
+		for _, adapter := range adapters.For(p, cfg) {
+			if cfg.Offline {
+				installed := python.InstalledVersion(dir, adapter.Package)
+				if installed == "" {
+					return adapters.OfflineError(adapter)
+				}
+				results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed})
+				continue
+			}
+
+			installed, added, err := python.InstallLibrary(py, dir, adapter.Package, adapter.Version)
+			if err != nil {
+				return err
+			}
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed, Added: added})
+		}
+		adapters.PrintReport(results)
+
+		return nil
+
+	case lang.JavaScript, lang.TypeScript:
+		err := node.InstallPackagesJSON(dir, cfg.PackageManager, cfg.Offline)
+		if err != nil {
+			return err
+		}
+
+		var results []adapters.Result
+		for _, adapter := range adapters.For(p, cfg) {
+			if cfg.Offline {
+				installed := node.InstalledVersion(dir, adapter.Package)
+				if installed == "" {
+					return adapters.OfflineError(adapter)
+				}
+				results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed})
+				continue
+			}
+
+			installed, added, err := node.InstallPackage(dir, adapter.Package, adapter.Version, cfg.PackageManager)
+			if err != nil {
+				return err
+			}
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed, Added: added})
+		}
+		adapters.PrintReport(results)
+
+		pkgJson, err := node.ParsePackageJSON(filepath.Join(dir, "package.json"))
+		if err != nil {
+			return fmt.Errorf("failed to parse package.json: %v", err)
+		}
+
+		if err := node.Build(dir, pkgJson, cfg.PackageManager); err != nil {
+			return err
+		}
+
+		if cfg.Language == lang.TypeScript && !cfg.BundleEnabled() {
+			if err := node.CompileTypeScript(dir); err != nil {
+				return err
+			}
+		}
+
+		if cfg.BundleEnabled() {
+			return node.Bundle(dir, node.BundleOptions{
+				Entrypoint: GetHandlerFileName(cfg.Language),
+				Target:     node.RuntimeTarget(runtime),
+				Externals:  cfg.Bundle.Externals,
+			})
+		}
+
+		return node.PruneDevDependencies(dir, cfg.PackageManager, cfg.Offline)
+
+	default:
+		return fmt.Errorf("unsupported language: %s", cfg.Language)
+	}
+}
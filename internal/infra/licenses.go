@@ -0,0 +1,71 @@
+package infra
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/sbom"
+)
+
+// CheckLicenses applies the license policy from the config to the packages
+// in the artifact, printing a table of the ones it rejects. In warn mode the
+// artifact is still built. Packages only declared in a manifest, for
+// platforms that install them at deploy time, are skipped with a warning
+// when their license isn't known
+func CheckLicenses(cfg *config.Config, components []sbom.Component) error {
+	if cfg.Licenses == nil {
+		return nil
+	}
+
+	policy := sbom.Policy{
+		Allow: cfg.Licenses.Allow,
+		Deny:  cfg.Licenses.Deny,
+	}
+	for _, exception := range cfg.Licenses.Exceptions {
+		policy.Exceptions = append(policy.Exceptions, sbom.Exception{
+			Package: exception.Package,
+			Version: exception.Version,
+			Reason:  exception.Reason,
+		})
+	}
+
+	if policy.Empty() {
+		return nil
+	}
+
+	var checked []sbom.Component
+	var unknown []string
+	for _, component := range components {
+		if component.Declared && len(component.Licenses) == 0 {
+			unknown = append(unknown, component.Name)
+			continue
+		}
+		checked = append(checked, component)
+	}
+	if len(unknown) > 0 {
+		fmt.Printf("Warning: skipping the license check for %d packages that are installed at deploy time, their licenses aren't known: %s\n", len(unknown), strings.Join(unknown, ", "))
+	}
+
+	violations := policy.Check(checked)
+	if len(violations) == 0 {
+		fmt.Printf("License check passed for %d packages\n", len(checked))
+		return nil
+	}
+
+	switch cfg.Licenses.Mode {
+	case "", "fail":
+		fmt.Printf("\n%d packages violate the license policy:\n\n", len(violations))
+		sbom.PrintViolations(os.Stdout, violations)
+		fmt.Println()
+		return fmt.Errorf("license check failed; remove these packages or add them to licenses.exceptions in .upify/config.yaml")
+	case "warn":
+		fmt.Printf("\nWarning: %d packages violate the license policy:\n\n", len(violations))
+		sbom.PrintViolations(os.Stdout, violations)
+		fmt.Println()
+		return nil
+	default:
+		return fmt.Errorf("unsupported licenses.mode: %s (use fail or warn)", cfg.Licenses.Mode)
+	}
+}
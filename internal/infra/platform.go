@@ -5,10 +5,46 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/codeupify/upify/internal/config"
 	"github.com/codeupify/upify/internal/platform"
 )
 
+// AddProvider sets up provider p from its HandlerCode and Templates: the
+// handler section for the project's language is appended to upify_handler
+// under section, the name UPIFY_DEPLOY_PLATFORM is set to once deployed,
+// and the environment's main.tf is rendered with replacements next to the
+// module's
+func AddProvider(cfg *config.Config, p platform.Provider, section string, replacements map[string]string) error {
+	fmt.Printf("Adding %s handlers...\n", p.Title())
+
+	handlerCode, err := p.HandlerCode(cfg.Language)
+	if err != nil {
+		return err
+	}
+
+	if err := AddPlatformHandler(cfg, section, handlerCode); err != nil {
+		return err
+	}
+
+	fmt.Printf("Setting up %s infrastructure...\n", p.Title())
+
+	environmentsMain, modulesMain := p.Templates()
+	return AddPlatform(p.Name(), RenderTemplate(environmentsMain, replacements), modulesMain)
+}
+
+// RenderTemplate replaces each placeholder in template, e.g. {REGION}, with
+// its value. The replacements are made in one pass, so values are never
+// taken for placeholders
+func RenderTemplate(template string, replacements map[string]string) string {
+	pairs := make([]string, 0, 2*len(replacements))
+	for placeholder, value := range replacements {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
 func AddPlatform(platform platform.Platform, environmentsMainContent string, modulesMainContent string) error {
 	environmentsDir := filepath.Join(".upify", "environments", "prod", string(platform))
 	if _, err := os.Stat(environmentsDir); err == nil {
@@ -63,10 +99,10 @@ func ListPlatforms() []string {
 	environmentsDir := filepath.Join(".upify", "environments")
 	result := []string{}
 
-	for _, platform := range platform.AllPlatforms {
-		platformDir := filepath.Join(environmentsDir, "prod", string(platform))
+	for _, name := range platform.Names() {
+		platformDir := filepath.Join(environmentsDir, "prod", name)
 		if _, err := os.Stat(platformDir); err == nil {
-			result = append(result, string(platform))
+			result = append(result, name)
 		}
 	}
 
@@ -0,0 +1,21 @@
+package infra
+
+import "testing"
+
+func TestRenderTemplate(t *testing.T) {
+	template := `name = "{NAME}"
+region = "{REGION}"
+other = "{OTHER}"`
+
+	got := RenderTemplate(template, map[string]string{
+		"{NAME}":   "app",
+		"{REGION}": "{NAME}",
+	})
+
+	want := `name = "app"
+region = "{NAME}"
+other = "{OTHER}"`
+	if got != want {
+		t.Errorf("RenderTemplate() = %q, want %q", got, want)
+	}
+}
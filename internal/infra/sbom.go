@@ -0,0 +1,149 @@
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/codeupify/upify/internal/sbom"
+)
+
+// UpifyVersion is recorded in SBOMs and deploy records
+var UpifyVersion string
+
+// WriteSBOM writes the packages in the artifact as an SBOM next to it, in the
+// format set in the config
+func WriteSBOM(cfg *config.Config, p platform.Platform, components []sbom.Component, zipPath string) error {
+	format, err := sbom.ParseFormat(cfg.SBOMFormat())
+	if err != nil {
+		return err
+	}
+
+	hash, err := fs.FileSHA256(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to hash artifact: %v", err)
+	}
+
+	doc := sbom.Document{
+		Name:         cfg.Name,
+		Platform:     string(p),
+		SHA256:       hash,
+		UpifyVersion: UpifyVersion,
+		Components:   components,
+	}
+
+	if err := sbom.Write(doc, format, sbom.Path(zipPath, format)); err != nil {
+		return fmt.Errorf("failed to write SBOM: %v", err)
+	}
+
+	return nil
+}
+
+// FindSBOM returns the file name and sha256 of the SBOM written next to an
+// artifact, or empty strings if there is none
+func FindSBOM(cfg *config.Config, zipPath string) (string, string, error) {
+	format, err := sbom.ParseFormat(cfg.SBOMFormat())
+	if err != nil {
+		return "", "", err
+	}
+
+	path := sbom.Path(zipPath, format)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", "", nil
+	}
+
+	hash, err := fs.FileSHA256(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash SBOM: %v", err)
+	}
+
+	return filepath.Base(path), hash, nil
+}
+
+// DeployRecord is written to the platform's environment directory after each
+// successful deploy, tying the deployed artifact to its SBOM
+type DeployRecord struct {
+	Platform       string    `json:"platform"`
+	ArtifactSHA256 string    `json:"artifact_sha256"`
+	Image          string    `json:"image,omitempty"`
+	SBOM           string    `json:"sbom,omitempty"`
+	SBOMSHA256     string    `json:"sbom_sha256,omitempty"`
+	UpifyVersion   string    `json:"upify_version,omitempty"`
+	DeployedAt     time.Time `json:"deployed_at"`
+}
+
+// RecordDeploy copies the artifact's SBOM into the platform's environment
+// directory and writes deployment.json next to it. imageRef is the pushed
+// image for image packages and empty for zips
+func RecordDeploy(cfg *config.Config, p platform.Platform, zipPath string, imageRef string) error {
+	artifactHash, err := fs.FileSHA256(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to hash artifact: %v", err)
+	}
+
+	record := DeployRecord{
+		Platform:       string(p),
+		ArtifactSHA256: artifactHash,
+		Image:          imageRef,
+		UpifyVersion:   UpifyVersion,
+		DeployedAt:     time.Now().UTC(),
+	}
+
+	format, err := sbom.ParseFormat(cfg.SBOMFormat())
+	if err != nil {
+		return err
+	}
+
+	sbomName, sbomHash, err := FindSBOM(cfg, zipPath)
+	if err != nil {
+		return err
+	}
+
+	envDir := GetPlatformTerraformDir(p)
+	if sbomName != "" {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(zipPath), sbomName))
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM: %v", err)
+		}
+
+		record.SBOM = sbom.FileName("sbom", format)
+		record.SBOMSHA256 = sbomHash
+		if err := os.WriteFile(filepath.Join(envDir, record.SBOM), data, 0644); err != nil {
+			return fmt.Errorf("failed to copy SBOM: %v", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	recordPath := filepath.Join(envDir, "deployment.json")
+	fmt.Printf("Writing %s...\n", recordPath)
+	return os.WriteFile(recordPath, data, 0644)
+}
+
+// ReadDeployRecord reads the platform's deployment.json, returning nil if it
+// hasn't been deployed yet
+func ReadDeployRecord(p platform.Platform) (*DeployRecord, error) {
+	recordPath := filepath.Join(GetPlatformTerraformDir(p), "deployment.json")
+	data, err := os.ReadFile(recordPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record DeployRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", recordPath, err)
+	}
+
+	return &record, nil
+}
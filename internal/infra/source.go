@@ -0,0 +1,56 @@
+package infra
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/lang/node"
+)
+
+// CopySource copies the project's source directory into dir, along with the
+// configured include_paths and, for Node.js, any local workspace or file:
+// dependencies it relies on
+func CopySource(cfg *config.Config, dir string) error {
+	sourceDir := cfg.GetSourceDir()
+	if err := fs.CopyFilesToTempDir(sourceDir, dir); err != nil {
+		return fmt.Errorf("failed to copy files to temp directory: %v", err)
+	}
+
+	for _, includePath := range cfg.IncludePaths {
+		if _, err := os.Stat(includePath); err != nil {
+			return fmt.Errorf("include path %s not found: %v", includePath, err)
+		}
+
+		dest := filepath.Join(dir, includeDestination(sourceDir, includePath))
+		fmt.Printf("Including %s...\n", includePath)
+		if err := fs.CopyPackageDir(includePath, dest); err != nil {
+			return fmt.Errorf("failed to copy include path %s: %v", includePath, err)
+		}
+	}
+
+	if cfg.Language == lang.JavaScript || cfg.Language == lang.TypeScript {
+		if _, err := node.VendorLocalDependencies(sourceDir, dir, cfg.PackageManager, cfg.Offline); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// includeDestination keeps an include path's location relative to the
+// source directory when it lives inside it, and places anything outside of
+// it (e.g. a shared/ directory next to the service) at the root of the
+// artifact
+func includeDestination(sourceDir string, includePath string) string {
+	relPath, err := filepath.Rel(sourceDir, includePath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return filepath.Base(filepath.Clean(includePath))
+	}
+
+	return relPath
+}
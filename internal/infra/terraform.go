@@ -131,3 +131,12 @@ func (m *TerraformManager) Apply(ctx context.Context, vars map[string]string) er
 func (m *TerraformManager) Output(ctx context.Context) (map[string]tfexec.OutputMeta, error) {
 	return m.tf.Output(ctx)
 }
+
+func (m *TerraformManager) Destroy(ctx context.Context, vars map[string]string) error {
+	var destroyOpts []tfexec.DestroyOption
+	for key, value := range vars {
+		destroyOpts = append(destroyOpts, tfexec.Var(fmt.Sprintf("%s=%s", key, value)))
+	}
+
+	return m.tf.Destroy(ctx, destroyOpts...)
+}
@@ -11,7 +11,12 @@ const (
 type PackageManager string
 
 const (
-	Pip  PackageManager = "pip"
-	Npm  PackageManager = "npm"
-	Yarn PackageManager = "yarn"
+	Pip    PackageManager = "pip"
+	Poetry PackageManager = "poetry"
+	Pipenv PackageManager = "pipenv"
+	Uv     PackageManager = "uv"
+	Npm    PackageManager = "npm"
+	Yarn   PackageManager = "yarn"
+	Pnpm   PackageManager = "pnpm"
+	Bun    PackageManager = "bun"
 )
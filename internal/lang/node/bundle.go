@@ -0,0 +1,356 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/codeupify/upify/internal/fs"
+)
+
+// Enables source map support in Node.js so stack traces point at the
+// original files instead of the minified bundle
+const sourceMapBanner = "process.setSourceMapsEnabled && process.setSourceMapsEnabled(true);"
+
+var nodeRuntimePattern = regexp.MustCompile(`node(?:js)?(\d+)`)
+
+// Defines require in ES module bundles, which esbuild's require calls for
+// Node.js built-ins fall back to
+const requireBanner = "import { createRequire } from 'node:module'; const require = createRequire('/');"
+
+type BundleOptions struct {
+	Entrypoint string
+	Target     string
+	Externals  []string
+	// Workers bundles an ES module for the Cloudflare Workers runtime, leaving
+	// Node.js built-ins and cloudflare: modules to its nodejs_compat layer
+	Workers bool
+}
+
+// Bundle uses esbuild to bundle the entrypoint in dir and everything it
+// imports into a single minified file with a source map. The contents of dir
+// are replaced with the bundle, plus node_modules for any external packages
+// (native modules and those listed in opts.Externals) and their dependencies.
+// The package.json of every bundled package is kept too, so the SBOM and the
+// license and audit gates still see what went into the bundle
+func Bundle(dir string, opts BundleOptions) error {
+	esbuild, err := findBinary(dir, "esbuild", "install it with `npm install --save-dev esbuild`")
+	if err != nil {
+		return err
+	}
+
+	nativeModules, err := FindNativeModules(dir)
+	if err != nil {
+		return fmt.Errorf("failed to detect native modules: %v", err)
+	}
+
+	externals := append(append([]string{}, opts.Externals...), nativeModules...)
+	if len(nativeModules) > 0 {
+		fmt.Printf("Keeping native modules external: %s\n", strings.Join(nativeModules, ", "))
+	}
+
+	outDir := dir + "_bundle"
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create bundle directory: %v", err)
+	}
+	defer os.RemoveAll(outDir)
+
+	metaFile := dir + "_meta.json"
+	defer os.Remove(metaFile)
+
+	outFile := filepath.Join(outDir, strings.TrimSuffix(filepath.Base(opts.Entrypoint), filepath.Ext(opts.Entrypoint))+".js")
+
+	args := []string{
+		opts.Entrypoint,
+		"--bundle",
+		"--platform=node",
+		"--minify",
+		"--keep-names",
+		"--sourcemap",
+		"--outfile=" + outFile,
+		"--metafile=" + metaFile,
+	}
+	if opts.Workers {
+		args = append(args, "--format=esm", "--conditions=workerd,worker", "--external:cloudflare:*", "--banner:js="+requireBanner)
+	} else {
+		args = append(args, "--format=cjs", "--banner:js="+sourceMapBanner)
+	}
+	if opts.Target != "" {
+		args = append(args, "--target="+opts.Target)
+	}
+	for _, external := range externals {
+		args = append(args, "--external:"+external)
+	}
+
+	fmt.Println("Bundling Node.js project with esbuild...")
+	bundleCmd := exec.Command(esbuild, args...)
+	bundleCmd.Dir = dir
+	bundleCmd.Stdout = os.Stdout
+	bundleCmd.Stderr = os.Stderr
+	if err := bundleCmd.Run(); err != nil {
+		return fmt.Errorf("failed to bundle Node.js project: %v", err)
+	}
+
+	if err := copyExternalPackages(dir, outDir, externals); err != nil {
+		return fmt.Errorf("failed to copy external packages: %v", err)
+	}
+
+	if err := copyBundledManifests(dir, outDir, metaFile); err != nil {
+		return fmt.Errorf("failed to record bundled packages: %v", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear staging directory: %v", err)
+	}
+
+	return fs.CopyDir(outDir, dir)
+}
+
+// RuntimeTarget converts a platform runtime such as nodejs20.x, nodejs20 or node20
+// to an esbuild target such as node20
+func RuntimeTarget(runtime string) string {
+	match := nodeRuntimePattern.FindStringSubmatch(runtime)
+	if match == nil {
+		return ""
+	}
+
+	return "node" + match[1]
+}
+
+// FindNativeModules returns the installed packages in dir/node_modules that
+// ship compiled addons and so can't be bundled
+func FindNativeModules(dir string) ([]string, error) {
+	packages, err := listInstalledPackages(filepath.Join(dir, "node_modules"))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, pkg := range packages {
+		native, err := isNativeModule(filepath.Join(dir, "node_modules", pkg))
+		if err != nil {
+			return nil, err
+		}
+		if native {
+			result = append(result, pkg)
+		}
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// findBinary looks for a Node.js tool in the staging directory, then the
+// project's own node_modules, then PATH
+func findBinary(dir string, name string, installHint string) (string, error) {
+	binary := name
+	if runtime.GOOS == "windows" {
+		binary = name + ".cmd"
+	}
+
+	candidates := []string{
+		filepath.Join(dir, "node_modules", ".bin", binary),
+		filepath.Join("node_modules", ".bin", binary),
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return filepath.Abs(candidate)
+		}
+	}
+
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+
+	return "", fmt.Errorf("%s not found; %s or add it to your PATH", name, installHint)
+}
+
+func listInstalledPackages(nodeModules string) ([]string, error) {
+	entries, err := os.ReadDir(nodeModules)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		if !strings.HasPrefix(entry.Name(), "@") {
+			result = append(result, entry.Name())
+			continue
+		}
+
+		scoped, err := os.ReadDir(filepath.Join(nodeModules, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range scoped {
+			if pkg.IsDir() {
+				result = append(result, entry.Name()+"/"+pkg.Name())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func isNativeModule(pkgDir string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(pkgDir, "binding.gyp")); err == nil {
+		return true, nil
+	}
+
+	var manifest struct {
+		GypFile bool `json:"gypfile"`
+	}
+	if data, err := os.ReadFile(filepath.Join(pkgDir, "package.json")); err == nil {
+		if json.Unmarshal(data, &manifest) == nil && manifest.GypFile {
+			return true, nil
+		}
+	}
+
+	native := false
+	err := filepath.Walk(pkgDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == "node_modules" && path != pkgDir {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".node" {
+			native = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+
+	return native, err
+}
+
+// copyExternalPackages copies each external package and the packages it
+// depends on from srcDir/node_modules to destDir/node_modules
+func copyExternalPackages(srcDir string, destDir string, externals []string) error {
+	copied := map[string]bool{}
+	queue := append([]string{}, externals...)
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if copied[name] {
+			continue
+		}
+		copied[name] = true
+
+		src := filepath.Join(srcDir, "node_modules", name)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := fs.CopyDir(src, filepath.Join(destDir, "node_modules", name)); err != nil {
+			return err
+		}
+
+		var manifest struct {
+			Dependencies         map[string]string `json:"dependencies"`
+			OptionalDependencies map[string]string `json:"optionalDependencies"`
+		}
+		data, err := os.ReadFile(filepath.Join(src, "package.json"))
+		if err != nil {
+			continue
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("failed to parse %s/package.json: %v", name, err)
+		}
+
+		for dep := range manifest.Dependencies {
+			queue = append(queue, dep)
+		}
+		for dep := range manifest.OptionalDependencies {
+			queue = append(queue, dep)
+		}
+	}
+
+	return nil
+}
+
+// copyBundledManifests copies the package.json of each package esbuild read
+// from node_modules to the same path under destDir, skipping the external
+// packages already copied whole
+func copyBundledManifests(srcDir string, destDir string, metaFile string) error {
+	data, err := os.ReadFile(metaFile)
+	if err != nil {
+		return err
+	}
+
+	var meta struct {
+		Inputs map[string]json.RawMessage `json:"inputs"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return fmt.Errorf("failed to parse esbuild metafile: %v", err)
+	}
+
+	copied := map[string]bool{}
+	for input := range meta.Inputs {
+		pkgDir := bundledPackageDir(input)
+		if pkgDir == "" || copied[pkgDir] {
+			continue
+		}
+		copied[pkgDir] = true
+
+		dest := filepath.Join(destDir, filepath.FromSlash(pkgDir), "package.json")
+		if _, err := os.Stat(dest); err == nil {
+			continue
+		}
+
+		manifest, err := os.ReadFile(filepath.Join(srcDir, filepath.FromSlash(pkgDir), "package.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, manifest, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bundledPackageDir returns the package directory of an esbuild input path,
+// e.g. node_modules/@scope/pkg for node_modules/@scope/pkg/lib/index.js, or
+// an empty string for the project's own files
+func bundledPackageDir(input string) string {
+	i := strings.LastIndex(input, "node_modules/")
+	if i < 0 {
+		return ""
+	}
+
+	prefix := input[:i+len("node_modules/")]
+	parts := strings.Split(input[len(prefix):], "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	if strings.HasPrefix(parts[0], "@") {
+		if len(parts) < 3 {
+			return ""
+		}
+		return prefix + parts[0] + "/" + parts[1]
+	}
+
+	return prefix + parts[0]
+}
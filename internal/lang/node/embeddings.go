@@ -6,3 +6,9 @@ import (
 
 //go:embed templates/node_main.tmpl
 var NodeMainTemplate string
+
+//go:embed templates/typescript_main.tmpl
+var TypeScriptMainTemplate string
+
+//go:embed templates/serverless_http.js.tmpl
+var ServerlessHttpShim string
@@ -0,0 +1,234 @@
+package node
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeupify/upify/internal/lang"
+)
+
+type packageManagerCommands struct {
+	// Installs all dependencies, including devDependencies
+	install []string
+	// Same as install, but fails if the lockfile is out of sync
+	frozenInstall []string
+	// Removes devDependencies from an existing node_modules
+	prune []string
+	// Same as prune, but fails if the lockfile is out of sync, nil if prune
+	// doesn't install anything
+	frozenPrune []string
+	// Adds a package to package.json and installs it
+	add func(packageName string) []string
+	// Runs a package.json script
+	run func(script string) []string
+	// Updates the lockfile with a new dependency without installing it, nil
+	// if the package manager can't, in which case it's added with add
+	lockOnly func(packageSpec string) []string
+	// Updates the lockfile to match package.json without installing, nil if
+	// the package manager can't
+	relock []string
+	// Keeps install commands off the network, nil if the package manager
+	// has no such mode
+	offline   []string
+	lockfiles []string
+}
+
+var packageManagers = map[lang.PackageManager]packageManagerCommands{
+	lang.Npm: {
+		install:       []string{"install", "--include=dev"},
+		frozenInstall: []string{"ci", "--include=dev"},
+		prune:         []string{"prune", "--omit=dev"},
+		add:           func(name string) []string { return []string{"install", name, "--save"} },
+		run:           func(script string) []string { return []string{"run", script} },
+		lockOnly: func(spec string) []string {
+			return []string{"install", "--package-lock-only", "--ignore-scripts", spec}
+		},
+		relock:    []string{"install", "--package-lock-only", "--ignore-scripts"},
+		offline:   []string{"--offline"},
+		lockfiles: []string{"package-lock.json"},
+	},
+	// Yarn 1, see yarnBerry for Yarn 2+
+	lang.Yarn: {
+		install:       []string{"install", "--production=false"},
+		frozenInstall: []string{"install", "--frozen-lockfile", "--production=false"},
+		// Yarn has no prune command, but a production install removes
+		// anything that isn't a production dependency
+		prune:       []string{"install", "--production", "--ignore-scripts", "--prefer-offline"},
+		frozenPrune: []string{"install", "--production", "--ignore-scripts", "--prefer-offline", "--frozen-lockfile"},
+		add:         func(name string) []string { return []string{"add", name} },
+		run:         func(script string) []string { return []string{"run", script} },
+		offline:     []string{"--offline"},
+		lockfiles:   []string{"yarn.lock"},
+	},
+	// pnpm is run with node-linker=hoisted (see configurePnpm) so that
+	// node_modules is a flat tree of real directories rather than symlinks
+	// into a content-addressed store, which wouldn't survive zipping
+	lang.Pnpm: {
+		install:       []string{"install"},
+		frozenInstall: []string{"install", "--frozen-lockfile"},
+		prune:         []string{"prune", "--prod"},
+		add:           func(name string) []string { return []string{"add", name} },
+		run:           func(script string) []string { return []string{"run", script} },
+		lockOnly: func(spec string) []string {
+			return []string{"add", spec, "--lockfile-only", "--ignore-scripts"}
+		},
+		relock:    []string{"install", "--lockfile-only", "--ignore-scripts"},
+		offline:   []string{"--offline"},
+		lockfiles: []string{"pnpm-lock.yaml"},
+	},
+	lang.Bun: {
+		install:       []string{"install"},
+		frozenInstall: []string{"install", "--frozen-lockfile"},
+		// Bun has no prune command, PruneDevDependencies reinstalls from
+		// scratch with --production instead
+		prune:       []string{"install", "--production"},
+		frozenPrune: []string{"install", "--production", "--frozen-lockfile"},
+		add:         func(name string) []string { return []string{"add", name} },
+		run:         func(script string) []string { return []string{"run", script} },
+		lockfiles:   []string{"bun.lock", "bun.lockb"},
+	},
+}
+
+// yarnBerry replaces packageManagers[lang.Yarn] for Yarn 2+ projects, which
+// dropped the Yarn 1 install flags. Yarn 2+ has no offline mode
+var yarnBerry = packageManagerCommands{
+	install:       []string{"install"},
+	frozenInstall: []string{"install", "--immutable"},
+	// Built into Yarn 4, Yarn 2 and 3 need the workspace-tools plugin
+	prune: []string{"workspaces", "focus", "--all", "--production"},
+	add:   func(name string) []string { return []string{"add", name} },
+	run:   func(script string) []string { return []string{"run", script} },
+	lockOnly: func(spec string) []string {
+		return []string{"add", spec, "--mode", "update-lockfile"}
+	},
+	relock:    []string{"install", "--mode", "update-lockfile"},
+	lockfiles: []string{"yarn.lock"},
+}
+
+// withOffline appends the flags that keep the package manager off the
+// network when offline is set
+func withOffline(args []string, commands packageManagerCommands, packageManager lang.PackageManager, offline bool) []string {
+	if !offline {
+		return args
+	}
+
+	if commands.offline == nil {
+		fmt.Printf("Warning: %s has no offline mode, so it may still use the registry\n", packageManager)
+		return args
+	}
+
+	return append(append([]string{}, args...), commands.offline...)
+}
+
+func getPackageManager(packageManager lang.PackageManager) (packageManagerCommands, error) {
+	commands, ok := packageManagers[packageManager]
+	if !ok {
+		return packageManagerCommands{}, fmt.Errorf("unsupported package manager: %s", packageManager)
+	}
+
+	return commands, nil
+}
+
+// projectPackageManager is getPackageManager for the project in dir, telling
+// Yarn 1 projects from Yarn 2+ ones
+func projectPackageManager(dir string, packageManager lang.PackageManager) (packageManagerCommands, error) {
+	if packageManager == lang.Yarn && !isYarnClassic(dir) {
+		return yarnBerry, nil
+	}
+
+	return getPackageManager(packageManager)
+}
+
+// RunScriptCommand returns the shell command that runs a package.json script
+// with the given package manager, e.g. "pnpm run build"
+func RunScriptCommand(packageManager lang.PackageManager, script string) string {
+	commands, err := getPackageManager(packageManager)
+	if err != nil {
+		return "npm run " + script
+	}
+
+	return string(packageManager) + " " + strings.Join(commands.run(script), " ")
+}
+
+// BuildpackScriptCommand is RunScriptCommand for scripts run by Google's
+// Node.js buildpack, which has npm, yarn and pnpm but no bun
+func BuildpackScriptCommand(packageManager lang.PackageManager, script string) string {
+	if packageManager == lang.Bun {
+		return RunScriptCommand(lang.Npm, script)
+	}
+
+	return RunScriptCommand(packageManager, script)
+}
+
+// InstallCommand returns the shell command that installs all dependencies of
+// the project in dir, frozen to the lockfile when locked is set, e.g.
+// "npm ci --include=dev"
+func InstallCommand(dir string, packageManager lang.PackageManager, locked bool) string {
+	commands, err := projectPackageManager(dir, packageManager)
+	if err != nil {
+		return "npm install --include=dev"
+	}
+
+	args := commands.install
+	if locked {
+		args = commands.frozenInstall
+	}
+
+	return string(packageManager) + " " + strings.Join(args, " ")
+}
+
+// PruneCommand returns the shell command that removes devDependencies once
+// the build is done in dir, the same way PruneDevDependencies does
+func PruneCommand(dir string, packageManager lang.PackageManager, locked bool) string {
+	commands, err := projectPackageManager(dir, packageManager)
+	if err != nil {
+		return "npm prune --omit=dev"
+	}
+
+	args := commands.prune
+	if locked && commands.frozenPrune != nil {
+		args = commands.frozenPrune
+	}
+
+	command := string(packageManager) + " " + strings.Join(args, " ")
+	if packageManager == lang.Bun {
+		command = "rm -rf node_modules && " + command
+	}
+
+	return command
+}
+
+// configurePnpm makes pnpm install a hoisted, symlink-free node_modules in
+// dir, which is the layout Lambda and zip artifacts need
+func configurePnpm(dir string) error {
+	npmrcPath := filepath.Join(dir, ".npmrc")
+	content, err := os.ReadFile(npmrcPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if strings.Contains(string(content), "node-linker") {
+		return nil
+	}
+
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte("node-linker=hoisted\n")...)
+
+	return os.WriteFile(npmrcPath, content, 0644)
+}
+
+// isYarnClassic tells Yarn 1 projects from Yarn 2+ ones, by the YAML
+// lockfile of Yarn 2+ or, without a lockfile, by its .yarnrc.yml
+func isYarnClassic(dir string) bool {
+	content, err := os.ReadFile(filepath.Join(dir, "yarn.lock"))
+	if err != nil {
+		_, err := os.Stat(filepath.Join(dir, ".yarnrc.yml"))
+		return err != nil
+	}
+
+	return !strings.Contains(string(content), "__metadata:")
+}
@@ -0,0 +1,97 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeupify/upify/internal/lang"
+)
+
+func TestYarnCommands(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		locked      bool
+		wantInstall string
+		wantPrune   string
+	}{
+		{
+			name:        "yarn 1 without lockfile",
+			wantInstall: "yarn install --production=false",
+			wantPrune:   "yarn install --production --ignore-scripts --prefer-offline",
+		},
+		{
+			name:        "yarn 1 lockfile",
+			files:       map[string]string{"yarn.lock": "# yarn lockfile v1\n"},
+			locked:      true,
+			wantInstall: "yarn install --frozen-lockfile --production=false",
+			wantPrune:   "yarn install --production --ignore-scripts --prefer-offline --frozen-lockfile",
+		},
+		{
+			name:        "yarn 2+ lockfile",
+			files:       map[string]string{"yarn.lock": "__metadata:\n  version: 8\n"},
+			locked:      true,
+			wantInstall: "yarn install --immutable",
+			wantPrune:   "yarn workspaces focus --all --production",
+		},
+		{
+			name:        "yarn 2+ without lockfile",
+			files:       map[string]string{".yarnrc.yml": "nodeLinker: node-modules\n"},
+			wantInstall: "yarn install",
+			wantPrune:   "yarn workspaces focus --all --production",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := InstallCommand(dir, lang.Yarn, tt.locked); got != tt.wantInstall {
+				t.Errorf("InstallCommand = %q, want %q", got, tt.wantInstall)
+			}
+			if got := PruneCommand(dir, lang.Yarn, tt.locked); got != tt.wantPrune {
+				t.Errorf("PruneCommand = %q, want %q", got, tt.wantPrune)
+			}
+		})
+	}
+}
+
+func TestWithOffline(t *testing.T) {
+	tests := []struct {
+		name     string
+		lockfile string
+		want     []string
+	}{
+		{name: "yarn 1", lockfile: "# yarn lockfile v1\n", want: []string{"install", "--offline"}},
+		{name: "yarn 2+", lockfile: "__metadata:\n  version: 8\n", want: []string{"install"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "yarn.lock"), []byte(tt.lockfile), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			commands, err := projectPackageManager(dir, lang.Yarn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := withOffline([]string{"install"}, commands, lang.Yarn, true)
+			if len(got) != len(tt.want) {
+				t.Fatalf("withOffline = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("withOffline = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
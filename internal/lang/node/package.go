@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	"github.com/codeupify/upify/internal/lang"
 )
@@ -74,63 +75,197 @@ func WritePackageJSON(path string, pkg *PackageJSON) error {
 	return os.WriteFile(path, data, 0644)
 }
 
-func InstallPackagesJSON(dir string, packageManager lang.PackageManager) error {
-	fmt.Printf("Installing package.json dependencies...\n")
-	var installCmd *exec.Cmd
-	if packageManager == lang.Npm {
-		installCmd = exec.Command("npm", "install", "--production")
+// InstallPackagesJSON installs every dependency in package.json, including
+// devDependencies, so that build scripts relying on tools like typescript can
+// run. When a lockfile is present the install is frozen to it and fails if it
+// is out of sync with package.json. Call PruneDevDependencies once the build
+// is done. With offline set the install is done from the package manager's
+// cache
+func InstallPackagesJSON(dir string, packageManager lang.PackageManager, offline bool) error {
+	commands, err := projectPackageManager(dir, packageManager)
+	if err != nil {
+		return err
+	}
+
+	if packageManager == lang.Pnpm {
+		if err := configurePnpm(dir); err != nil {
+			return fmt.Errorf("failed to configure pnpm: %v", err)
+		}
+	}
+
+	args := commands.install
+	locked := HasLockfile(dir, packageManager)
+	if locked {
+		fmt.Printf("Installing package.json dependencies from %s...\n", LockfileName(dir, packageManager))
+		args = commands.frozenInstall
 	} else {
-		installCmd = exec.Command("yarn", "install", "--production")
+		fmt.Printf("Installing package.json dependencies...\n")
 	}
 
-	installCmd.Dir = dir
-	installCmd.Stdout = os.Stdout
-	installCmd.Stderr = os.Stderr
-	if err := installCmd.Run(); err != nil {
+	if err := runPackageManager(dir, packageManager, withOffline(args, commands, packageManager, offline)); err != nil {
+		if locked {
+			return fmt.Errorf("failed to install Node.js dependencies, check that %s is in sync with package.json: %v", LockfileName(dir, packageManager), err)
+		}
 		return fmt.Errorf("failed to install Node.js dependencies: %v", err)
 	}
 
 	return nil
 }
 
-func InstallPackage(dir string, packageName string, packageManager lang.PackageManager) error {
-	installed := isPackageInstalled(dir, packageName, packageManager)
-	if installed {
+// PruneDevDependencies removes devDependencies from node_modules after the
+// build, leaving only what the deployed app needs at runtime. Yarn and Bun
+// prune by reinstalling, from their cache with offline set
+func PruneDevDependencies(dir string, packageManager lang.PackageManager, offline bool) error {
+	commands, err := projectPackageManager(dir, packageManager)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Pruning devDependencies...")
+	args := commands.prune
+	if packageManager == lang.Bun {
+		if err := os.RemoveAll(filepath.Join(dir, "node_modules")); err != nil {
+			return fmt.Errorf("failed to remove node_modules: %v", err)
+		}
+	}
+	if HasLockfile(dir, packageManager) && commands.frozenPrune != nil {
+		args = commands.frozenPrune
+	}
+
+	if packageManager == lang.Yarn || packageManager == lang.Bun {
+		args = withOffline(args, commands, packageManager, offline)
+	}
+
+	if err := runPackageManager(dir, packageManager, args); err != nil {
+		if packageManager == lang.Yarn && !isYarnClassic(dir) {
+			return fmt.Errorf("failed to prune Node.js devDependencies, Yarn 2 and 3 need the workspace-tools plugin (yarn plugin import workspace-tools): %v", err)
+		}
+		return fmt.Errorf("failed to prune Node.js devDependencies: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateLockfile records a dependency added to package.json in the lockfile,
+// so that installs frozen to the lockfile on the platform side don't fail.
+// Package managers that can't update the lockfile alone install the
+// dependency into dir to update it, leaving no node_modules behind if there
+// wasn't one
+func UpdateLockfile(dir string, packageName string, version string, packageManager lang.PackageManager) error {
+	if !HasLockfile(dir, packageManager) {
 		return nil
 	}
 
-	fmt.Printf("Installing package: %s...\n", packageName)
-	var installCmd *exec.Cmd
-	if packageManager == lang.Npm {
-		installCmd = exec.Command("npm", "install", packageName, "--save")
-	} else {
-		installCmd = exec.Command("yarn", "add", packageName, "--save")
+	commands, err := projectPackageManager(dir, packageManager)
+	if err != nil {
+		return err
+	}
+
+	lockfile := LockfileName(dir, packageManager)
+	spec := packageName + "@" + version
+
+	if commands.lockOnly != nil {
+		fmt.Printf("Adding %s to %s...\n", packageName, lockfile)
+		if err := runPackageManager(dir, packageManager, commands.lockOnly(spec)); err != nil {
+			return fmt.Errorf("failed to update %s: %v", lockfile, err)
+		}
+		return nil
+	}
+
+	fmt.Printf("Warning: %s can't update %s without installing, installing %s to add it\n", packageManager, lockfile, spec)
+
+	nodeModules := filepath.Join(dir, "node_modules")
+	_, statErr := os.Stat(nodeModules)
+	if err := runPackageManager(dir, packageManager, append(commands.add(spec), "--ignore-scripts")); err != nil {
+		return fmt.Errorf("failed to update %s: %v", lockfile, err)
 	}
 
-	installCmd.Dir = dir
-	installCmd.Stdout = os.Stdout
-	installCmd.Stderr = os.Stderr
-	if err := installCmd.Run(); err != nil {
-		return fmt.Errorf("failed to install Node.js dependency: %v", err)
+	if os.IsNotExist(statErr) {
+		if err := os.RemoveAll(nodeModules); err != nil {
+			return fmt.Errorf("failed to remove node_modules: %v", err)
+		}
 	}
 
 	return nil
 }
 
+// LockfileName returns the name of the lockfile the package manager uses in
+// dir, or its preferred lockfile name if there is none yet
+func LockfileName(dir string, packageManager lang.PackageManager) string {
+	commands, err := getPackageManager(packageManager)
+	if err != nil {
+		return ""
+	}
+
+	for _, lockfile := range commands.lockfiles {
+		if _, err := os.Stat(filepath.Join(dir, lockfile)); err == nil {
+			return lockfile
+		}
+	}
+
+	return commands.lockfiles[0]
+}
+
+func HasLockfile(dir string, packageManager lang.PackageManager) bool {
+	name := LockfileName(dir, packageManager)
+	if name == "" {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+// InstallPackage adds packageName@version to the project in dir unless some
+// version of it is already installed, e.g. as one of the project's own
+// dependencies. Returns the version present in node_modules and whether it
+// was added
+func InstallPackage(dir string, packageName string, version string, packageManager lang.PackageManager) (string, bool, error) {
+	commands, err := getPackageManager(packageManager)
+	if err != nil {
+		return "", false, err
+	}
+
+	if installed := InstalledVersion(dir, packageName); installed != "" {
+		return installed, false, nil
+	}
+
+	fmt.Printf("Installing package: %s@%s...\n", packageName, version)
+	if err := runPackageManager(dir, packageManager, commands.add(packageName+"@"+version)); err != nil {
+		return "", false, fmt.Errorf("failed to install Node.js dependency: %v", err)
+	}
+
+	return version, true, nil
+}
+
+// InstalledVersion returns the version of a package installed in
+// dir/node_modules, read from its package.json, or an empty string if it
+// isn't installed
+func InstalledVersion(dir string, packageName string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "node_modules", packageName, "package.json"))
+	if err != nil {
+		return ""
+	}
+
+	var manifest struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return ""
+	}
+
+	return manifest.Version
+}
+
 func Build(dir string, pkg *PackageJSON, packageManager lang.PackageManager) error {
+	commands, err := getPackageManager(packageManager)
+	if err != nil {
+		return err
+	}
+
 	if _, hasBuild := pkg.Scripts["build"]; hasBuild {
 		fmt.Println("Building Node.js project...")
-		var buildCmd *exec.Cmd
-		if packageManager == lang.Npm {
-			buildCmd = exec.Command("npm", "run", "build")
-		} else {
-			buildCmd = exec.Command("yarn", "build")
-		}
-
-		buildCmd.Dir = dir
-		buildCmd.Stdout = os.Stdout
-		buildCmd.Stderr = os.Stderr
-		if err := buildCmd.Run(); err != nil {
+		if err := runPackageManager(dir, packageManager, commands.run("build")); err != nil {
 			return fmt.Errorf("failed to build Node.js project: %v", err)
 		}
 		fmt.Println("Successfully built Node.js project")
@@ -159,23 +294,10 @@ func SetMainInPackageJSON(pkg *PackageJSON, mainFile string) {
 	pkg.Main = mainFile
 }
 
-func isPackageInstalled(dir, packageName string, packageManager lang.PackageManager) bool {
-	var listCmd *exec.Cmd
-
-	if packageManager == lang.Npm {
-		listCmd = exec.Command("npm", "list", packageName, "--depth=0")
-	} else {
-		listCmd = exec.Command("yarn", "list", "--pattern", packageName)
-	}
-
-	listCmd.Dir = dir
-	listCmd.Stdout = nil
-	listCmd.Stderr = nil
-
-	if err := listCmd.Run(); err == nil {
-
-		return true
-	}
-
-	return false
+func runPackageManager(dir string, packageManager lang.PackageManager, args []string) error {
+	cmd := exec.Command(string(packageManager), args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
 }
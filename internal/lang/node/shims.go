@@ -0,0 +1,49 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// shims maps adapter packages to the minimal replacement embedded in upify
+var shims = map[string]string{
+	"serverless-http": ServerlessHttpShim,
+}
+
+func HasShim(packageName string) bool {
+	_, ok := shims[packageName]
+	return ok
+}
+
+// WriteShim writes the embedded shim for packageName into dir/node_modules,
+// so that offline builds don't need to download it. Call it after
+// PruneDevDependencies, which would remove it as extraneous
+func WriteShim(dir string, packageName string) error {
+	source, ok := shims[packageName]
+	if !ok {
+		return fmt.Errorf("no embedded shim for %s", packageName)
+	}
+
+	packageDir := filepath.Join(dir, "node_modules", packageName)
+	if err := os.MkdirAll(packageDir, 0755); err != nil {
+		return err
+	}
+
+	manifest, err := json.MarshalIndent(map[string]string{
+		"name":    packageName,
+		"version": "0.0.0-upify",
+		"main":    "index.js",
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Writing embedded %s shim...\n", packageName)
+	if err := os.WriteFile(filepath.Join(packageDir, "package.json"), manifest, 0644); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(packageDir, "index.js"), []byte(source), 0644)
+}
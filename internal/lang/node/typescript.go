@@ -0,0 +1,226 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	TypeScriptHandlerFile = "upify_handler.ts"
+	UpifyTSConfigFile     = "tsconfig.upify.json"
+)
+
+type TSConfig struct {
+	CompilerOptions struct {
+		OutDir string `json:"outDir"`
+	} `json:"compilerOptions"`
+}
+
+// PrepareTypeScript writes tsconfig.upify.json to dir, extending the project's
+// tsconfig.json (if any) so that upify_handler.ts is compiled with source maps
+// alongside the rest of the project. If the compiled handler doesn't end up
+// at the root of dir, an upify_handler.js shim that re-exports it is written
+// so platforms can keep loading upify_handler.js. The command that compiles
+// the project is returned
+func PrepareTypeScript(dir string) (string, error) {
+	upifyConfig := map[string]interface{}{
+		"files": []string{TypeScriptHandlerFile},
+	}
+
+	compilerOptions := map[string]interface{}{
+		"sourceMap": true,
+		"noEmit":    false,
+		"rootDir":   ".",
+	}
+
+	outDir := "."
+	tsConfigPath := filepath.Join(dir, "tsconfig.json")
+	if _, err := os.Stat(tsConfigPath); err == nil {
+		tsConfig, err := ParseTSConfig(tsConfigPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse tsconfig.json: %v", err)
+		}
+
+		upifyConfig["extends"] = "./tsconfig.json"
+		if tsConfig.CompilerOptions.OutDir != "" {
+			outDir = path.Clean(filepath.ToSlash(tsConfig.CompilerOptions.OutDir))
+		}
+	} else {
+		compilerOptions["module"] = "commonjs"
+		compilerOptions["target"] = "es2020"
+		compilerOptions["esModuleInterop"] = true
+		compilerOptions["skipLibCheck"] = true
+	}
+	upifyConfig["compilerOptions"] = compilerOptions
+
+	data, err := json.MarshalIndent(upifyConfig, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, UpifyTSConfigFile), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", UpifyTSConfigFile, err)
+	}
+
+	if outDir != "." {
+		compiledHandler := "./" + path.Join(outDir, "upify_handler.js")
+		shim := fmt.Sprintf("module.exports = require('%s');\n", compiledHandler)
+		if err := os.WriteFile(filepath.Join(dir, "upify_handler.js"), []byte(shim), 0644); err != nil {
+			return "", fmt.Errorf("failed to write upify_handler.js: %v", err)
+		}
+	}
+
+	return "tsc -p " + UpifyTSConfigFile, nil
+}
+
+// CompileTypeScript compiles the project in dir with tsc, using the config
+// written by PrepareTypeScript
+func CompileTypeScript(dir string) error {
+	if _, err := PrepareTypeScript(dir); err != nil {
+		return err
+	}
+
+	tsc, err := findBinary(dir, "tsc", "install it with `npm install --save-dev typescript`")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Compiling TypeScript project...")
+	compileCmd := exec.Command(tsc, "-p", UpifyTSConfigFile)
+	compileCmd.Dir = dir
+	compileCmd.Stdout = os.Stdout
+	compileCmd.Stderr = os.Stderr
+	if err := compileCmd.Run(); err != nil {
+		return fmt.Errorf("failed to compile TypeScript project: %v", err)
+	}
+
+	return nil
+}
+
+// CompiledHandler returns where tsc writes upify_handler.js for the project
+// in dir, relative to it: under the outDir of its tsconfig.json, if it sets
+// one. Servers have to start that file, since require.main isn't the handler
+// when it is loaded through the shim PrepareTypeScript writes
+func CompiledHandler(dir string) (string, error) {
+	tsConfigPath := filepath.Join(dir, "tsconfig.json")
+	if _, err := os.Stat(tsConfigPath); err != nil {
+		return "upify_handler.js", nil
+	}
+
+	tsConfig, err := ParseTSConfig(tsConfigPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse tsconfig.json: %v", err)
+	}
+	if tsConfig.CompilerOptions.OutDir == "" {
+		return "upify_handler.js", nil
+	}
+
+	return path.Join(path.Clean(filepath.ToSlash(tsConfig.CompilerOptions.OutDir)), "upify_handler.js"), nil
+}
+
+// ParseTSConfig reads a tsconfig.json, which unlike plain JSON may contain
+// comments and trailing commas
+func ParseTSConfig(path string) (*TSConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tsConfig TSConfig
+	if err := json.Unmarshal([]byte(stripJSONC(string(data))), &tsConfig); err != nil {
+		return nil, err
+	}
+
+	return &tsConfig, nil
+}
+
+// stripJSONC removes the comments and then the trailing commas, so that a
+// comma followed by a comment before the closing bracket is dropped too
+func stripJSONC(input string) string {
+	return stripTrailingCommas(stripComments(input))
+}
+
+func stripComments(input string) string {
+	var out strings.Builder
+	inString := false
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+
+		if inString {
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(input) {
+				i++
+				out.WriteByte(input[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '/' && i+1 < len(input) && input[i+1] == '/':
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+		case c == '/' && i+1 < len(input) && input[i+1] == '*':
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				return out.String()
+			}
+			i += end + 3
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.String()
+}
+
+func stripTrailingCommas(input string) string {
+	var out strings.Builder
+	inString := false
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+
+		if inString {
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(input) {
+				i++
+				out.WriteByte(input[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+			out.WriteByte(c)
+		case ',':
+			j := i + 1
+			for j < len(input) && strings.ContainsRune(" \t\r\n", rune(input[j])) {
+				j++
+			}
+			if j < len(input) && (input[j] == '}' || input[j] == ']') {
+				continue
+			}
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.String()
+}
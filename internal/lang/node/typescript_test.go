@@ -0,0 +1,39 @@
+package node
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain", input: `{"outDir": "dist"}`, want: "dist"},
+		{name: "line comment", input: "{\n  // built\n  \"outDir\": \"dist\"\n}", want: "dist"},
+		{name: "block comment", input: `{/* built */ "outDir": "dist"}`, want: "dist"},
+		{name: "trailing comma", input: "{\"outDir\": \"dist\",\n}", want: "dist"},
+		{name: "comma before comment", input: `{"outDir": "dist", /* built */}`, want: "dist"},
+		{name: "comma before line comment", input: "{\"outDir\": \"dist\", // built\n}", want: "dist"},
+		{name: "comment in string", input: `{"outDir": "dist//a/*b*/"}`, want: "dist//a/*b*/"},
+		{name: "comma in string", input: `{"outDir": "a,}"}`, want: "a,}"},
+		{name: "escaped quote", input: `{"outDir": "a\",}"}`, want: `a",}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options struct {
+				OutDir string `json:"outDir"`
+			}
+			stripped := stripJSONC(tt.input)
+			if err := json.Unmarshal([]byte(stripped), &options); err != nil {
+				t.Fatalf("failed to parse %q: %v", stripped, err)
+			}
+			if options.OutDir != tt.want {
+				t.Errorf("outDir = %q, want %q", options.OutDir, tt.want)
+			}
+		})
+	}
+}
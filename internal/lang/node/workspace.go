@@ -0,0 +1,316 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/lang"
+	"gopkg.in/yaml.v2"
+)
+
+// LocalPackagesDir is where local path and workspace dependencies are copied
+// to inside the staging directory
+const LocalPackagesDir = "local_packages"
+
+var unsafePackageChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// VendorLocalDependencies copies the local packages that the project in
+// sourceDir depends on, through file:/link: paths or workspace:/npm
+// workspaces, into stagingDir/local_packages, and rewrites package.json
+// files in stagingDir to point at the copies. Local packages with a build
+// script are built in place, installing their dependencies from the cache
+// when offline is set. Returns true if any dependency was rewritten
+func VendorLocalDependencies(sourceDir string, stagingDir string, packageManager lang.PackageManager, offline bool) (bool, error) {
+	workspacePackages, err := findWorkspacePackages(sourceDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve workspace packages: %v", err)
+	}
+
+	vendored := map[string]string{}
+	rewritten, err := vendorDependencies(sourceDir, stagingDir, stagingDir, workspacePackages, vendored, packageManager, offline)
+	if err != nil {
+		return false, err
+	}
+
+	if rewritten && HasLockfile(stagingDir, packageManager) {
+		if err := relock(stagingDir, packageManager, offline); err != nil {
+			return false, err
+		}
+	}
+
+	return rewritten, nil
+}
+
+// relock brings the lockfile in dir in line with the rewritten local
+// dependencies, keeping the other packages pinned. Package managers that
+// can't do that without installing get the lockfile removed instead, so
+// installs aren't frozen
+func relock(dir string, packageManager lang.PackageManager, offline bool) error {
+	commands, err := projectPackageManager(dir, packageManager)
+	if err != nil {
+		return err
+	}
+	lockfile := LockfileName(dir, packageManager)
+
+	if commands.relock != nil {
+		fmt.Printf("Updating %s for the rewritten local dependencies...\n", lockfile)
+		if err := runPackageManager(dir, packageManager, withOffline(commands.relock, commands, packageManager, offline)); err != nil {
+			return fmt.Errorf("failed to update %s: %v", lockfile, err)
+		}
+		return nil
+	}
+
+	fmt.Printf("Warning: %s can't update %s for the rewritten local dependencies without installing, so it's removed and installs won't be frozen\n", packageManager, lockfile)
+	for _, name := range commands.lockfiles {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// vendorDependencies rewrites the local dependencies of the package.json in
+// pkgDir (a copy of originalDir) and recursively vendors those packages
+func vendorDependencies(originalDir string, pkgDir string, stagingDir string, workspacePackages map[string]string, vendored map[string]string, packageManager lang.PackageManager, offline bool) (bool, error) {
+	pkgJsonPath := filepath.Join(pkgDir, "package.json")
+	pkgJson, err := ParsePackageJSON(pkgJsonPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s: %v", pkgJsonPath, err)
+	}
+
+	// devDependencies are installed to build the project, so their local
+	// packages need vendoring too
+	devDependencies := map[string]string{}
+	if devDeps, ok := pkgJson.Other["devDependencies"].(map[string]interface{}); ok {
+		for name, version := range devDeps {
+			devDependencies[name] = fmt.Sprint(version)
+		}
+	}
+
+	rewritten := false
+	for _, deps := range []map[string]string{pkgJson.Dependencies, devDependencies} {
+		for name, version := range deps {
+			localDir := resolveLocalDependency(originalDir, name, version, workspacePackages)
+			if localDir == "" {
+				continue
+			}
+
+			vendoredPath, ok := vendored[localDir]
+			if !ok {
+				vendoredPath = filepath.Join(stagingDir, LocalPackagesDir, unsafePackageChars.ReplaceAllString(name, "_"))
+				vendored[localDir] = vendoredPath
+
+				fmt.Printf("Copying local dependency %s from %s...\n", name, localDir)
+				if err := fs.CopyPackageDir(localDir, vendoredPath); err != nil {
+					return false, fmt.Errorf("failed to copy local dependency %s: %v", name, err)
+				}
+
+				if _, err := vendorDependencies(localDir, vendoredPath, stagingDir, workspacePackages, vendored, packageManager, offline); err != nil {
+					return false, err
+				}
+
+				if err := buildLocalPackage(vendoredPath, packageManager, offline); err != nil {
+					return false, fmt.Errorf("failed to build local dependency %s: %v", name, err)
+				}
+			}
+
+			relPath, err := filepath.Rel(pkgDir, vendoredPath)
+			if err != nil {
+				return false, err
+			}
+
+			relPath = filepath.ToSlash(relPath)
+			if !strings.HasPrefix(relPath, "../") {
+				relPath = "./" + relPath
+			}
+
+			deps[name] = "file:" + relPath
+			rewritten = true
+		}
+	}
+
+	if len(devDependencies) > 0 {
+		pkgJson.Other["devDependencies"] = devDependencies
+	}
+
+	if !rewritten {
+		return false, nil
+	}
+
+	return true, WritePackageJSON(pkgJsonPath, pkgJson)
+}
+
+// resolveLocalDependency returns the directory of a dependency if it is a
+// local package, or an empty string if it should come from the registry
+func resolveLocalDependency(pkgDir string, name string, version string, workspacePackages map[string]string) string {
+	for _, prefix := range []string{"file:", "link:", "portal:"} {
+		if strings.HasPrefix(version, prefix) {
+			path := strings.TrimPrefix(version, prefix)
+			if strings.HasSuffix(path, ".tgz") || strings.HasSuffix(path, ".tar.gz") {
+				return ""
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(pkgDir, path)
+			}
+			return path
+		}
+	}
+
+	if strings.HasPrefix(version, "workspace:") {
+		return workspacePackages[name]
+	}
+
+	// npm and yarn v1 workspaces link a package by name from any semver range
+	if dir, ok := workspacePackages[name]; ok && !strings.Contains(version, ":") {
+		return dir
+	}
+
+	return ""
+}
+
+func buildLocalPackage(dir string, packageManager lang.PackageManager, offline bool) error {
+	pkgJson, err := ParsePackageJSON(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return err
+	}
+
+	if _, hasBuild := pkgJson.Scripts["build"]; !hasBuild {
+		return nil
+	}
+
+	if err := InstallPackagesJSON(dir, packageManager, offline); err != nil {
+		return err
+	}
+
+	if err := Build(dir, pkgJson, packageManager); err != nil {
+		return err
+	}
+
+	// The package's runtime dependencies are installed along with the
+	// project's, so its own node_modules isn't needed anymore
+	return os.RemoveAll(filepath.Join(dir, "node_modules"))
+}
+
+// findWorkspacePackages walks up from dir looking for an npm/yarn workspace
+// (package.json "workspaces") or a pnpm workspace (pnpm-workspace.yaml) and
+// returns its packages by name
+func findWorkspacePackages(dir string) (map[string]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for current := absDir; ; current = filepath.Dir(current) {
+		patterns, err := readWorkspacePatterns(current)
+		if err != nil {
+			return nil, err
+		}
+
+		if patterns != nil {
+			return resolveWorkspacePatterns(current, patterns)
+		}
+
+		if filepath.Dir(current) == current {
+			return map[string]string{}, nil
+		}
+	}
+}
+
+func readWorkspacePatterns(dir string) ([]string, error) {
+	if data, err := os.ReadFile(filepath.Join(dir, "pnpm-workspace.yaml")); err == nil {
+		var pnpmWorkspace struct {
+			Packages []string `yaml:"packages"`
+		}
+		if err := yaml.Unmarshal(data, &pnpmWorkspace); err != nil {
+			return nil, fmt.Errorf("failed to parse pnpm-workspace.yaml: %v", err)
+		}
+		return pnpmWorkspace.Packages, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, nil
+	}
+
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil || len(manifest.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(manifest.Workspaces, &patterns); err == nil {
+		return patterns, nil
+	}
+
+	var yarnWorkspaces struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(manifest.Workspaces, &yarnWorkspaces); err != nil {
+		return nil, fmt.Errorf("failed to parse workspaces in %s/package.json: %v", dir, err)
+	}
+
+	return yarnWorkspaces.Packages, nil
+}
+
+func resolveWorkspacePatterns(root string, patterns []string) (map[string]string, error) {
+	result := map[string]string{}
+
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			continue
+		}
+
+		var dirs []string
+		if strings.Contains(pattern, "**") {
+			base := filepath.Join(root, strings.SplitN(pattern, "**", 2)[0])
+			err := filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return nil
+				}
+				if info.IsDir() && (info.Name() == "node_modules" || info.Name() == ".git") {
+					return filepath.SkipDir
+				}
+				if info.IsDir() {
+					dirs = append(dirs, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			matches, err := filepath.Glob(filepath.Join(root, pattern))
+			if err != nil {
+				return nil, err
+			}
+			dirs = matches
+		}
+
+		for _, dir := range dirs {
+			data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+			if err != nil {
+				continue
+			}
+
+			var manifest struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(data, &manifest) == nil && manifest.Name != "" {
+				result[manifest.Name] = dir
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -6,3 +6,6 @@ import (
 
 //go:embed templates/python_main.tmpl
 var PythonMainTemplate string
+
+//go:embed templates/apig_wsgi.py.tmpl
+var ApigWsgiShim string
@@ -0,0 +1,122 @@
+package python
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Interpreter is the Python used to run pip, so that dependencies are
+// resolved for the same Python version the project runs on
+type Interpreter struct {
+	Path    string
+	Version string
+}
+
+// FindInterpreter resolves the Python interpreter to install dependencies
+// with, in order of preference: the configured path, the active virtualenv
+// ($VIRTUAL_ENV), a .venv or venv directory in sourceDir or the current
+// directory, then python3 or python on the PATH
+func FindInterpreter(sourceDir string, configured string) (*Interpreter, error) {
+	if configured != "" {
+		path, err := exec.LookPath(configured)
+		if err != nil {
+			return nil, fmt.Errorf("configured Python interpreter %s not found: %v", configured, err)
+		}
+		return newInterpreter(path)
+	}
+
+	var candidates []string
+	if venv := os.Getenv("VIRTUAL_ENV"); venv != "" {
+		candidates = append(candidates, venvPython(venv))
+	}
+	for _, dir := range []string{sourceDir, "."} {
+		for _, venv := range []string{".venv", "venv"} {
+			candidates = append(candidates, venvPython(filepath.Join(dir, venv)))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return newInterpreter(candidate)
+		}
+	}
+
+	for _, name := range []string{"python3", "python"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return newInterpreter(path)
+		}
+	}
+
+	return nil, fmt.Errorf("no Python interpreter found; create a virtualenv in .venv, add python3 to your PATH or set python.interpreter in .upify/config.yaml")
+}
+
+func venvPython(venv string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venv, "Scripts", "python.exe")
+	}
+	return filepath.Join(venv, "bin", "python")
+}
+
+func newInterpreter(path string) (*Interpreter, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := exec.Command(absPath, "-c", "import sys; print('%d.%d' % sys.version_info[:2])").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run Python interpreter %s: %v", absPath, err)
+	}
+
+	return &Interpreter{Path: absPath, Version: strings.TrimSpace(string(output))}, nil
+}
+
+// CheckRuntime fails if the interpreter's major.minor version differs from
+// the platform runtime (e.g. python3.12 or python312), since packages with
+// native extensions are installed for the interpreter's version
+func (py *Interpreter) CheckRuntime(runtime string) error {
+	expected := RuntimeVersion(runtime)
+	if expected == "" || expected == py.Version {
+		return nil
+	}
+
+	return fmt.Errorf("%s is Python %s but the platform runtime is %s; use a Python %s virtualenv or set python.interpreter in .upify/config.yaml",
+		py.Path, py.Version, runtime, expected)
+}
+
+// RuntimeVersion returns the major.minor version of a platform runtime name,
+// or an empty string if it isn't a Python runtime
+func RuntimeVersion(runtime string) string {
+	if !strings.HasPrefix(runtime, "python") {
+		return ""
+	}
+
+	version := strings.TrimPrefix(runtime, "python")
+	if strings.Contains(version, ".") || len(version) < 2 {
+		return version
+	}
+
+	return version[:1] + "." + version[1:]
+}
+
+// Pip runs `python -m pip` with the interpreter
+func (py *Interpreter) Pip(dir string, args ...string) error {
+	cmd := exec.Command(py.Path, append([]string{"-m", "pip"}, args...)...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// CheckPip fails with an install hint if pip isn't available to the
+// interpreter
+func (py *Interpreter) CheckPip() error {
+	if err := exec.Command(py.Path, "-m", "pip", "--version").Run(); err != nil {
+		return fmt.Errorf("pip is not available for %s; install it with `%s -m ensurepip`", py.Path, py.Path)
+	}
+	return nil
+}
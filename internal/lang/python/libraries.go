@@ -3,59 +3,144 @@ package python
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/codeupify/upify/internal/lang"
 )
 
-func InstallRequirements(dir string) error {
+var nameSeparators = regexp.MustCompile(`[-_.]+`)
+
+func InstallRequirements(py *Interpreter, dir string, sourceDir string, packageManager lang.PackageManager, offline bool) error {
 	requirementsFile := filepath.Join(dir, "requirements.txt")
 
-	if _, err := os.Stat(requirementsFile); os.IsNotExist(err) {
-		fmt.Println("No requirements.txt found; skipping installation...")
+	found, err := WriteRequirements(dir, sourceDir, packageManager)
+	if err != nil {
+		return err
+	}
+	if !found {
+		fmt.Println("No requirements.txt or pyproject.toml dependencies found; skipping installation...")
 		return nil
 	}
 
-	cmd := exec.Command("pip", "install", "-r", requirementsFile, "-t", dir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	args := []string{"install", "-r", requirementsFile, "-t", dir}
+	hashed, err := hasHashes(requirementsFile)
+	if err != nil {
+		return fmt.Errorf("failed to read requirements.txt: %v", err)
+	}
+	if hashed {
+		fmt.Println("requirements.txt has hashes; installing with --require-hashes...")
+		args = append(args, "--require-hashes")
+	}
+
+	if offline {
+		// Packages come from PIP_FIND_LINKS, e.g. a directory of wheels
+		args = append(args, "--no-index")
+	}
+
+	fmt.Printf("Installing requirements with %s (Python %s)...\n", py.Path, py.Version)
+	// Local requirements are relative to the staging directory
+	if err := py.Pip(dir, args...); err != nil {
+		if hashed {
+			return fmt.Errorf("failed to install Python requirements, check that every requirement is pinned with a matching hash: %v", err)
+		}
 		return fmt.Errorf("failed to install Python requirements: %v", err)
 	}
 
 	return nil
 }
 
-func InstallLibrary(dir string, library string) error {
-	installed, err := isLibraryInstalled(dir, library)
+// hasHashes reports whether a requirements file is a lock, i.e. was
+// generated with hashes by a tool like `pip-compile --generate-hashes`
+func hasHashes(requirementsFile string) (bool, error) {
+	content, err := os.ReadFile(requirementsFile)
 	if err != nil {
-		return fmt.Errorf("failed to check if library is installed: %v", err)
+		return false, err
 	}
 
-	if installed {
-		fmt.Printf("Library %s is already installed. Skipping installation.\n", library)
-		return nil
+	return strings.Contains(string(content), "--hash="), nil
+}
+
+// InstallLibrary installs library==version into dir unless some version of it
+// is already there, e.g. from the project's requirements. Returns the version
+// present in dir and whether it was added
+func InstallLibrary(py *Interpreter, dir string, library string, version string) (string, bool, error) {
+	if installed := InstalledVersion(dir, library); installed != "" {
+		return installed, false, nil
 	}
 
-	cmd := exec.Command("pip", "install", library, "-t", dir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to install %s: %v", library, err)
+	fmt.Printf("Installing %s==%s...\n", library, version)
+	if err := py.Pip(dir, "install", library+"=="+version, "-t", dir); err != nil {
+		return "", false, fmt.Errorf("failed to install %s: %v", library, err)
 	}
-	return nil
+
+	return version, true, nil
 }
 
-func isLibraryInstalled(dir string, library string) (bool, error) {
-	packageDir := filepath.Join(dir, library)
+// InstalledVersion returns the version of a distribution installed into dir
+// by reading its .dist-info (or legacy .egg-info) metadata, or an empty string
+// if it isn't installed. Distribution names are compared normalized, so
+// apig-wsgi matches apig_wsgi-2.18.0.dist-info
+func InstalledVersion(dir string, library string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	name := NormalizeName(library)
+	for _, entry := range entries {
+		var metadataFile string
+		switch {
+		case strings.HasSuffix(entry.Name(), ".dist-info"):
+			metadataFile = "METADATA"
+		case strings.HasSuffix(entry.Name(), ".egg-info"):
+			metadataFile = "PKG-INFO"
+		default:
+			continue
+		}
 
-	if _, err := os.Stat(packageDir); !os.IsNotExist(err) {
-		return true, nil
+		metadata, err := readMetadata(filepath.Join(dir, entry.Name(), metadataFile))
+		if err != nil {
+			continue
+		}
+
+		if NormalizeName(metadata["Name"]) == name {
+			return metadata["Version"]
+		}
 	}
 
-	eggInfoDir := filepath.Join(dir, library+".egg-info")
-	if _, err := os.Stat(eggInfoDir); !os.IsNotExist(err) {
-		return true, nil
+	return ""
+}
+
+// NormalizeName normalizes a distribution name as described in PEP 503
+func NormalizeName(name string) string {
+	return strings.ToLower(nameSeparators.ReplaceAllString(strings.TrimSpace(name), "-"))
+}
+
+// readMetadata reads the header fields of a core metadata file
+func readMetadata(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			// The body (long description) follows the first empty line
+			break
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if _, exists := fields[key]; !exists {
+			fields[key] = strings.TrimSpace(value)
+		}
 	}
 
-	return false, nil
+	return fields, nil
 }
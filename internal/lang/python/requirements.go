@@ -0,0 +1,343 @@
+package python
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/lang"
+)
+
+type PyProject struct {
+	Project struct {
+		Name           string   `toml:"name"`
+		RequiresPython string   `toml:"requires-python"`
+		Dependencies   []string `toml:"dependencies"`
+	} `toml:"project"`
+}
+
+type pipfileLockEntry struct {
+	Version string   `json:"version"`
+	Hashes  []string `json:"hashes"`
+	Markers string   `json:"markers"`
+	Extras  []string `json:"extras"`
+	Git     string   `json:"git"`
+	Ref     string   `json:"ref"`
+	Path    string   `json:"path"`
+	File    string   `json:"file"`
+}
+
+type pipfileLock struct {
+	Default map[string]pipfileLockEntry `json:"default"`
+}
+
+func ParsePyProject(path string) (*PyProject, error) {
+	var pyproject PyProject
+	if _, err := toml.DecodeFile(path, &pyproject); err != nil {
+		return nil, err
+	}
+
+	return &pyproject, nil
+}
+
+// LocalPackagesDir is where local path requirements are copied to inside the
+// staging directory
+const LocalPackagesDir = "local_packages"
+
+var requirementName = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?(.*)$`)
+
+// WriteRequirements makes sure dir/requirements.txt lists the project's
+// production dependencies. Poetry, Pipenv and uv projects have theirs exported
+// from the lockfile, and pip projects without a requirements.txt fall back to
+// the PEP 621 dependencies in pyproject.toml. Local path requirements are
+// resolved against sourceDir and copied into dir. Returns false if the
+// project declares no dependencies
+func WriteRequirements(dir string, sourceDir string, packageManager lang.PackageManager) (bool, error) {
+	found, err := writeRequirements(dir, packageManager)
+	if err != nil || !found {
+		return found, err
+	}
+
+	if err := vendorLocalRequirements(sourceDir, dir); err != nil {
+		return true, fmt.Errorf("failed to copy local requirements: %v", err)
+	}
+
+	return true, nil
+}
+
+func writeRequirements(dir string, packageManager lang.PackageManager) (bool, error) {
+	requirementsFile := filepath.Join(dir, "requirements.txt")
+
+	switch packageManager {
+	case lang.Poetry:
+		fmt.Println("Exporting requirements from poetry.lock...")
+		return true, runExport(dir, "poetry", "install it from https://python-poetry.org",
+			"export", "--format", "requirements.txt", "--only", "main", "--output", requirementsFile)
+
+	case lang.Uv:
+		fmt.Println("Exporting requirements from uv.lock...")
+		return true, runExport(dir, "uv", "install it from https://docs.astral.sh/uv",
+			"export", "--frozen", "--no-dev", "--no-emit-project", "--format", "requirements-txt", "--output-file", requirementsFile)
+
+	case lang.Pipenv:
+		fmt.Println("Exporting requirements from Pipfile.lock...")
+		return true, exportPipfileLock(filepath.Join(dir, "Pipfile.lock"), requirementsFile)
+
+	case lang.Pip:
+		if _, err := os.Stat(requirementsFile); err == nil {
+			return true, nil
+		}
+
+		pyprojectFile := filepath.Join(dir, "pyproject.toml")
+		if _, err := os.Stat(pyprojectFile); os.IsNotExist(err) {
+			return false, nil
+		}
+
+		pyproject, err := ParsePyProject(pyprojectFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse pyproject.toml: %v", err)
+		}
+
+		if len(pyproject.Project.Dependencies) == 0 {
+			return false, nil
+		}
+
+		fmt.Println("Writing requirements from pyproject.toml...")
+		content := strings.Join(pyproject.Project.Dependencies, "\n") + "\n"
+		return true, os.WriteFile(requirementsFile, []byte(content), 0644)
+
+	default:
+		return false, fmt.Errorf("unsupported package manager: %s", packageManager)
+	}
+}
+
+// vendorLocalRequirements copies requirements that point at local
+// directories (e.g. `-e ../shared`, `./libs/utils` or `name @ file:///...`)
+// into dir/local_packages and rewrites them to point at the copies, since
+// paths outside the artifact don't exist where it gets installed
+func vendorLocalRequirements(sourceDir string, dir string) error {
+	requirementsFile := filepath.Join(dir, "requirements.txt")
+	content, err := os.ReadFile(requirementsFile)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	rewritten := false
+	for i, line := range lines {
+		name, path, ok := parseLocalRequirement(line)
+		if !ok {
+			continue
+		}
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(sourceDir, path)
+		}
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			continue
+		}
+
+		if name == "" {
+			name = filepath.Base(path)
+		}
+		vendoredPath := filepath.Join(LocalPackagesDir, name)
+
+		fmt.Printf("Copying local requirement %s from %s...\n", name, path)
+		if err := fs.CopyPackageDir(path, filepath.Join(dir, vendoredPath)); err != nil {
+			return err
+		}
+
+		lines[i] = "./" + filepath.ToSlash(vendoredPath)
+		rewritten = true
+	}
+
+	if !rewritten {
+		return nil
+	}
+
+	return os.WriteFile(requirementsFile, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// parseLocalRequirement returns the package name (if known) and path of a
+// requirement line that installs from a local directory
+func parseLocalRequirement(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if i := strings.Index(line, " #"); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+
+	editable := false
+	for _, prefix := range []string{"-e ", "--editable "} {
+		if strings.HasPrefix(line, prefix) {
+			line = strings.TrimSpace(strings.TrimPrefix(line, prefix))
+			editable = true
+		}
+	}
+
+	name := ""
+	if parts := strings.SplitN(line, " @ ", 2); len(parts) == 2 {
+		name = strings.TrimSpace(parts[0])
+		if i := strings.Index(name, "["); i >= 0 {
+			name = name[:i]
+		}
+		line = strings.TrimSpace(parts[1])
+	}
+
+	// Drop environment markers and hashes
+	line = strings.TrimSpace(strings.SplitN(line, ";", 2)[0])
+	line = strings.TrimSpace(strings.SplitN(line, " --hash", 2)[0])
+
+	switch {
+	case strings.HasPrefix(line, "file://"):
+		return name, strings.TrimPrefix(line, "file://"), true
+	case strings.HasPrefix(line, "./"), strings.HasPrefix(line, "../"), strings.HasPrefix(line, "/"):
+		return name, line, true
+	case editable && line == ".":
+		// The project itself, which is already in the artifact
+		return "", "", false
+	case editable && !strings.Contains(line, "://"):
+		return name, line, true
+	}
+
+	return "", "", false
+}
+
+// AddRequirement appends library==version to dir/requirements.txt unless the
+// project already requires it, for platforms that install requirements
+// themselves. Returns the requirement already declared (or the pinned
+// version) and whether it was added
+func AddRequirement(dir string, library string, version string) (string, bool, error) {
+	requirementsFile := filepath.Join(dir, "requirements.txt")
+	content, err := os.ReadFile(requirementsFile)
+	if err != nil && !os.IsNotExist(err) {
+		return "", false, err
+	}
+
+	name := NormalizeName(library)
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "#", 2)[0])
+		match := requirementName.FindStringSubmatch(line)
+		if match != nil && NormalizeName(match[1]) == name {
+			declared := strings.TrimSpace(strings.SplitN(match[2], ";", 2)[0])
+			declared = strings.TrimSpace(strings.SplitN(declared, " --hash", 2)[0])
+			if declared == "" {
+				declared = "*"
+			}
+			return declared, false, nil
+		}
+	}
+
+	if strings.Contains(string(content), "--hash=") {
+		return "", false, fmt.Errorf("%s is not in requirements.txt, which has hashes; add %s==%s with its hashes and try again", library, library, version)
+	}
+
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte(library+"=="+version+"\n")...)
+
+	return version, true, os.WriteFile(requirementsFile, content, 0644)
+}
+
+func runExport(dir string, tool string, installHint string, args ...string) error {
+	if _, err := exec.LookPath(tool); err != nil {
+		return fmt.Errorf("%s not found; %s or add it to your PATH", tool, installHint)
+	}
+
+	cmd := exec.Command(tool, args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to export requirements with %s, check that the lockfile is up to date: %v", tool, err)
+	}
+
+	return nil
+}
+
+// exportPipfileLock converts the default (non-dev) section of Pipfile.lock to
+// requirements.txt format. Hashes are kept unless some entry lacks them (e.g.
+// VCS dependencies), since pip requires all or nothing
+func exportPipfileLock(lockPath string, requirementsFile string) error {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("failed to read Pipfile.lock: %v", err)
+	}
+
+	var lock pipfileLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return fmt.Errorf("failed to parse Pipfile.lock: %v", err)
+	}
+
+	names := make([]string, 0, len(lock.Default))
+	withHashes := true
+	for name, entry := range lock.Default {
+		names = append(names, name)
+		if len(entry.Hashes) == 0 && !isProjectPath(entry.Path) {
+			withHashes = false
+		}
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		entry := lock.Default[name]
+		if entry.Path != "" {
+			if isProjectPath(entry.Path) {
+				// The project itself, which is already in the artifact
+				continue
+			}
+			path := filepath.ToSlash(filepath.Clean(entry.Path))
+			if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "/") {
+				path = "./" + path
+			}
+			// Copied into the artifact by vendorLocalRequirements
+			lines = append(lines, name+" @ "+path)
+			continue
+		}
+
+		requirement := name
+		if len(entry.Extras) > 0 {
+			requirement += "[" + strings.Join(entry.Extras, ",") + "]"
+		}
+
+		switch {
+		case entry.Git != "":
+			requirement += " @ git+" + entry.Git
+			if entry.Ref != "" {
+				requirement += "@" + entry.Ref
+			}
+		case entry.File != "":
+			requirement += " @ " + entry.File
+		default:
+			requirement += entry.Version
+		}
+
+		if entry.Markers != "" {
+			requirement += " ; " + entry.Markers
+		}
+
+		if withHashes {
+			for _, hash := range entry.Hashes {
+				requirement += " --hash=" + hash
+			}
+		}
+
+		lines = append(lines, requirement)
+	}
+
+	return os.WriteFile(requirementsFile, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// isProjectPath reports whether a Pipfile.lock path entry is the project
+// itself, which is left out of the export
+func isProjectPath(path string) bool {
+	return path != "" && filepath.Clean(path) == "."
+}
@@ -0,0 +1,155 @@
+package python
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLocalRequirement(t *testing.T) {
+	tests := []struct {
+		line  string
+		name  string
+		path  string
+		local bool
+	}{
+		{line: "-e ../shared", path: "../shared", local: true},
+		{line: "--editable ../shared", path: "../shared", local: true},
+		{line: "-e libs/utils", path: "libs/utils", local: true},
+		{line: "./libs/utils", path: "./libs/utils", local: true},
+		{line: "/opt/libs/utils", path: "/opt/libs/utils", local: true},
+		{line: "utils @ file:///opt/libs/utils", name: "utils", path: "/opt/libs/utils", local: true},
+		{line: "utils[extra] @ ../utils", name: "utils", path: "../utils", local: true},
+		{line: `utils @ ../utils ; python_version >= "3.8"`, name: "utils", path: "../utils", local: true},
+		{line: "utils @ ../utils --hash=sha256:abc", name: "utils", path: "../utils", local: true},
+		{line: "  ../utils  # shared code", path: "../utils", local: true},
+		{line: "-e .", local: false},
+		{line: "-e git+https://github.com/org/repo.git#egg=repo", local: false},
+		{line: "requests @ https://example.com/requests.tar.gz", local: false},
+		{line: "requests==2.32.3", local: false},
+		{line: "# ../commented", local: false},
+		{line: "", local: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			name, path, local := parseLocalRequirement(tt.line)
+			if name != tt.name || path != tt.path || local != tt.local {
+				t.Errorf("parseLocalRequirement(%q) = %q, %q, %v, want %q, %q, %v", tt.line, name, path, local, tt.name, tt.path, tt.local)
+			}
+		})
+	}
+}
+
+func TestVendorLocalRequirements(t *testing.T) {
+	sourceDir := t.TempDir()
+	dir := t.TempDir()
+
+	for _, name := range []string{"shared", "utils"} {
+		if err := os.MkdirAll(filepath.Join(sourceDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(sourceDir, name, "setup.py"), []byte("from setuptools import setup\nsetup()\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	requirements := "requests==2.32.3\n-e ./shared\nmy-utils @ ./utils ; python_version >= \"3.8\"\n./missing\n-e .\n"
+	if err := os.WriteFile(filepath.Join(dir, "requirements.txt"), []byte(requirements), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vendorLocalRequirements(sourceDir, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "requirements.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "requests==2.32.3\n./local_packages/shared\n./local_packages/my-utils\n./missing\n-e .\n"
+	if string(content) != want {
+		t.Errorf("requirements.txt =\n%s\nwant\n%s", content, want)
+	}
+
+	for _, name := range []string{"shared", "my-utils"} {
+		if _, err := os.Stat(filepath.Join(dir, LocalPackagesDir, name, "setup.py")); err != nil {
+			t.Errorf("%s wasn't copied: %v", name, err)
+		}
+	}
+}
+
+func TestExportPipfileLock(t *testing.T) {
+	tests := []struct {
+		name string
+		lock string
+		want string
+	}{
+		{
+			name: "hashes",
+			lock: `{"default": {
+				"requests": {"version": "==2.32.3", "hashes": ["sha256:aaa", "sha256:bbb"]},
+				"flask": {"version": "==3.0.3", "hashes": ["sha256:ccc"], "extras": ["async"], "markers": "python_version >= '3.8'"}
+			}}`,
+			want: "flask[async]==3.0.3 ; python_version >= '3.8' --hash=sha256:ccc\n" +
+				"requests==2.32.3 --hash=sha256:aaa --hash=sha256:bbb\n",
+		},
+		{
+			// pip needs hashes for every requirement or none
+			name: "entry without hashes",
+			lock: `{"default": {
+				"requests": {"version": "==2.32.3", "hashes": ["sha256:aaa"]},
+				"repo": {"git": "https://github.com/org/repo.git", "ref": "abc123"}
+			}}`,
+			want: "repo @ git+https://github.com/org/repo.git@abc123\n" +
+				"requests==2.32.3\n",
+		},
+		{
+			name: "project path",
+			lock: `{"default": {
+				"app": {"path": ".", "editable": true},
+				"requests": {"version": "==2.32.3", "hashes": ["sha256:aaa"]}
+			}}`,
+			want: "requests==2.32.3 --hash=sha256:aaa\n",
+		},
+		{
+			name: "local path",
+			lock: `{"default": {
+				"utils": {"path": "libs/utils"},
+				"shared": {"path": "../shared"},
+				"requests": {"version": "==2.32.3", "hashes": ["sha256:aaa"]}
+			}}`,
+			want: "requests==2.32.3\n" +
+				"shared @ ../shared\n" +
+				"utils @ ./libs/utils\n",
+		},
+		{
+			name: "file",
+			lock: `{"default": {"pkg": {"file": "https://example.com/pkg-1.0.tar.gz"}}}`,
+			want: "pkg @ https://example.com/pkg-1.0.tar.gz\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			lockPath := filepath.Join(dir, "Pipfile.lock")
+			if err := os.WriteFile(lockPath, []byte(tt.lock), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			requirementsFile := filepath.Join(dir, "requirements.txt")
+			if err := exportPipfileLock(lockPath, requirementsFile); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(requirementsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("requirements.txt =\n%s\nwant\n%s", content, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package python
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// shims maps adapter distributions to the module name and source of the
+// minimal replacement embedded in upify
+var shims = map[string]struct {
+	module string
+	source string
+}{
+	"apig-wsgi": {module: "apig_wsgi", source: ApigWsgiShim},
+}
+
+func HasShim(library string) bool {
+	_, ok := shims[NormalizeName(library)]
+	return ok
+}
+
+// WriteShim writes the embedded shim for library into dir as an importable
+// package, so that offline builds don't need to download it
+func WriteShim(dir string, library string) error {
+	shim, ok := shims[NormalizeName(library)]
+	if !ok {
+		return fmt.Errorf("no embedded shim for %s", library)
+	}
+
+	moduleDir := filepath.Join(dir, shim.module)
+	if err := os.MkdirAll(moduleDir, 0755); err != nil {
+		return err
+	}
+
+	fmt.Printf("Writing embedded %s shim...\n", library)
+	return os.WriteFile(filepath.Join(moduleDir, "__init__.py"), []byte(shim.source), 0644)
+}
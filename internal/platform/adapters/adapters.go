@@ -0,0 +1,115 @@
+package adapters
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/framework"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed adapters.yaml
+var manifestYAML []byte
+
+const noFramework = "none"
+
+// Adapter is a package the upify handler needs on a platform, pinned to an
+// exact version
+type Adapter struct {
+	Platform   platform.Platform     `yaml:"platform"`
+	Languages  []lang.Language       `yaml:"languages"`
+	Frameworks []framework.Framework `yaml:"frameworks"`
+	Package    string                `yaml:"package"`
+	Version    string                `yaml:"version"`
+}
+
+// Result records what happened to an adapter while staging: either it was
+// added at the pinned version, written from the shim embedded in upify, or
+// the project already provides it
+type Result struct {
+	Adapter
+	InstalledVersion string
+	Added            bool
+	Shimmed          bool
+}
+
+var manifest []Adapter
+
+func init() {
+	if err := yaml.Unmarshal(manifestYAML, &manifest); err != nil {
+		panic(fmt.Sprintf("invalid adapters.yaml: %v", err))
+	}
+}
+
+// For returns the adapters a project needs on the given platform
+func For(p platform.Platform, cfg *config.Config) []Adapter {
+	fw := cfg.Framework
+	if fw == "" {
+		fw = noFramework
+	}
+
+	var result []Adapter
+	for _, adapter := range manifest {
+		if adapter.Platform != p {
+			continue
+		}
+		if len(adapter.Languages) > 0 && !contains(adapter.Languages, cfg.Language) {
+			continue
+		}
+		if len(adapter.Frameworks) > 0 && !contains(adapter.Frameworks, fw) {
+			continue
+		}
+		result = append(result, adapter)
+	}
+
+	return result
+}
+
+// Find returns the pinned adapter for a package on the given platform
+func Find(p platform.Platform, cfg *config.Config, pkg string) (Adapter, bool) {
+	for _, adapter := range For(p, cfg) {
+		if adapter.Package == pkg {
+			return adapter, true
+		}
+	}
+
+	return Adapter{}, false
+}
+
+// OfflineError explains that an adapter can't be downloaded in an offline
+// build and has no embedded shim
+func OfflineError(adapter Adapter) error {
+	return fmt.Errorf("%s is required on %s but is not a dependency of the project, and offline builds can't download it; add %s@%s to your dependencies",
+		adapter.Package, adapter.Platform, adapter.Package, adapter.Version)
+}
+
+// PrintReport lists the adapters that were added to the artifact and the ones
+// the project already provided
+func PrintReport(results []Result) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Println("Adapters:")
+	for _, result := range results {
+		if result.Shimmed {
+			fmt.Printf("  %-36s %-10s embedded shim\n", result.Package, "")
+		} else if result.Added {
+			fmt.Printf("  %-36s %-10s added\n", result.Package, result.Version)
+		} else {
+			fmt.Printf("  %-36s %-10s provided by project\n", result.Package, result.InstalledVersion)
+		}
+	}
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -7,19 +7,85 @@ import (
 	"path/filepath"
 
 	"github.com/codeupify/upify/internal/config"
-	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/image"
 	"github.com/codeupify/upify/internal/infra"
 	"github.com/codeupify/upify/internal/lang"
 	"github.com/codeupify/upify/internal/lang/node"
 	"github.com/codeupify/upify/internal/lang/python"
 	"github.com/codeupify/upify/internal/platform"
+	"github.com/codeupify/upify/internal/platform/adapters"
 )
 
-func Deploy(cfg *config.Config) error {
+// Deploy applies the platform's terraform configuration. When artifactPath is
+// empty the project is staged and zipped first, otherwise the prebuilt
+// artifact is deployed as is
+func Deploy(cfg *config.Config, artifactPath string) error {
+	if cfg.ImagePackaging() {
+		return deployImage(cfg, artifactPath)
+	}
+
+	if artifactPath == "" {
+		if err := infra.PreDeployValidate(cfg, platform.AWS); err != nil {
+			return err
+		}
+	} else if err := infra.ValidateTerraformDir(platform.AWS); err != nil {
+		return err
+	}
+
+	if err := infra.WriteEnvironmentVariables(platform.AWS); err != nil {
+		return err
+	}
+
+	if artifactPath == "" {
+		tempDir, err := os.MkdirTemp("", "lambda_deployment_")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		stagingDir := filepath.Join(tempDir, "source")
+		if err := Stage(cfg, stagingDir); err != nil {
+			return err
+		}
+
+		artifactPath = filepath.Join(tempDir, "source.zip")
+		if err := infra.CreateArtifact(cfg, platform.AWS, stagingDir, artifactPath); err != nil {
+			return err
+		}
+	}
+
+	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.AWS))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	vars := map[string]string{
+		"source_zip_path": artifactPath,
+	}
+
+	ctx := context.Background()
+	if err := terraformManager.Apply(ctx, vars); err != nil {
+		return err
+	}
+
+	return infra.RecordDeploy(cfg, platform.AWS, artifactPath, "")
+}
+
+// deployImage builds the project into a container image on the Lambda base
+// image, pushes it and points the function at it
+func deployImage(cfg *config.Config, artifactPath string) error {
+	if artifactPath != "" {
+		return fmt.Errorf("--artifact is only supported when package_type is zip")
+	}
+
 	if err := infra.PreDeployValidate(cfg, platform.AWS); err != nil {
 		return err
 	}
 
+	if err := infra.ValidateImageTemplate(platform.AWS); err != nil {
+		return err
+	}
+
 	if err := infra.WriteEnvironmentVariables(platform.AWS); err != nil {
 		return err
 	}
@@ -30,21 +96,20 @@ func Deploy(cfg *config.Config) error {
 	}
 	defer os.RemoveAll(tempDir)
 
-	err = fs.CopyFilesToTempDir(".", tempDir)
-	if err != nil {
-		return fmt.Errorf("failed to copy files to temp directory: %v", err)
+	stagingDir := filepath.Join(tempDir, "source")
+	if err := Stage(cfg, stagingDir); err != nil {
+		return err
 	}
 
-	err = installRequirements(tempDir, cfg)
+	tarPath := filepath.Join(tempDir, "image.tar")
+	img, err := infra.CreateImage(cfg, platform.AWS, stagingDir, ImageConfig(cfg), filepath.Join(tempDir, "image"), tarPath)
 	if err != nil {
-		return fmt.Errorf("failed to install requirements: %v", err)
+		return err
 	}
 
-	zipPath := filepath.Join(tempDir, "source.zip")
-	fmt.Printf("Creating %s...\n", zipPath)
-	err = fs.CreateZip(tempDir, zipPath)
+	imageRef, err := infra.PushImage(cfg, img)
 	if err != nil {
-		return fmt.Errorf("failed to create zip: %v", err)
+		return err
 	}
 
 	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.AWS))
@@ -53,7 +118,8 @@ func Deploy(cfg *config.Config) error {
 	}
 
 	vars := map[string]string{
-		"source_zip_path": zipPath,
+		"source_zip_path": "",
+		"image_uri":       imageRef,
 	}
 
 	ctx := context.Background()
@@ -61,49 +127,147 @@ func Deploy(cfg *config.Config) error {
 		return err
 	}
 
+	return infra.RecordDeploy(cfg, platform.AWS, tarPath, imageRef)
+}
+
+// ImageConfig runs the handler with the runtime interface client of the
+// Lambda base images (public.ecr.aws/lambda/*), from /var/task like zips
+func ImageConfig(cfg *config.Config) image.RuntimeConfig {
+	return image.RuntimeConfig{
+		AppDir:     "/var/task",
+		WorkingDir: "/var/task",
+		Cmd:        []string{"upify_handler.handler"},
+	}
+}
+
+// Stage copies the project into dir and installs its dependencies along with
+// the Lambda adapters, leaving dir ready to be zipped
+func Stage(cfg *config.Config, dir string) error {
+	err := infra.CopySource(cfg, dir)
+	if err != nil {
+		return err
+	}
+
+	err = installRequirements(dir, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to install requirements: %v", err)
+	}
+
 	return nil
 }
 
 func installRequirements(dir string, cfg *config.Config) error {
 	switch cfg.Language {
 	case lang.Python:
-		err := python.InstallRequirements(dir)
+		py, err := python.FindInterpreter(cfg.GetSourceDir(), cfg.PythonInterpreter())
 		if err != nil {
 			return err
 		}
 
-		err = python.InstallLibrary(dir, "flask")
-		if err != nil {
+		if err := py.CheckRuntime(infra.GetPlatformRuntime(platform.AWS)); err != nil {
 			return err
 		}
 
-		err = python.InstallLibrary(dir, "apig-wsgi")
-		if err != nil {
+		if err := py.CheckPip(); err != nil {
 			return err
 		}
 
-	case lang.JavaScript, lang.TypeScript:
-		err := node.InstallPackagesJSON(dir, cfg.PackageManager)
+		err = python.InstallRequirements(py, dir, cfg.GetSourceDir(), cfg.PackageManager, cfg.Offline)
 		if err != nil {
 			return err
 		}
 
-		err = node.InstallPackage(dir, "express", cfg.PackageManager)
-		if err != nil {
-			return err
+		var results []adapters.Result
+		for _, adapter := range adapters.For(platform.AWS, cfg) {
+			if cfg.Offline {
+				if installed := python.InstalledVersion(dir, adapter.Package); installed != "" {
+					results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed})
+					continue
+				}
+				if !python.HasShim(adapter.Package) {
+					return adapters.OfflineError(adapter)
+				}
+				if err := python.WriteShim(dir, adapter.Package); err != nil {
+					return fmt.Errorf("failed to write %s shim: %v", adapter.Package, err)
+				}
+				results = append(results, adapters.Result{Adapter: adapter, Shimmed: true})
+				continue
+			}
+
+			installed, added, err := python.InstallLibrary(py, dir, adapter.Package, adapter.Version)
+			if err != nil {
+				return err
+			}
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed, Added: added})
 		}
+		adapters.PrintReport(results)
 
-		err = node.InstallPackage(dir, "serverless-http", cfg.PackageManager)
+	case lang.JavaScript, lang.TypeScript:
+		err := node.InstallPackagesJSON(dir, cfg.PackageManager, cfg.Offline)
 		if err != nil {
 			return err
 		}
 
+		// Shims are written once the build is done, since pruning would
+		// remove them from node_modules
+		var results []adapters.Result
+		var shims []string
+		for _, adapter := range adapters.For(platform.AWS, cfg) {
+			if cfg.Offline {
+				if installed := node.InstalledVersion(dir, adapter.Package); installed != "" {
+					results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed})
+					continue
+				}
+				if !node.HasShim(adapter.Package) {
+					return adapters.OfflineError(adapter)
+				}
+				shims = append(shims, adapter.Package)
+				results = append(results, adapters.Result{Adapter: adapter, Shimmed: true})
+				continue
+			}
+
+			installed, added, err := node.InstallPackage(dir, adapter.Package, adapter.Version, cfg.PackageManager)
+			if err != nil {
+				return err
+			}
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed, Added: added})
+		}
+		adapters.PrintReport(results)
+
 		pkgJson, err := node.ParsePackageJSON(filepath.Join(dir, "package.json"))
 		if err != nil {
 			return fmt.Errorf("failed to parse package.json: %v", err)
 		}
 
-		node.Build(dir, pkgJson, cfg.PackageManager)
+		if err := node.Build(dir, pkgJson, cfg.PackageManager); err != nil {
+			return err
+		}
+
+		if cfg.Language == lang.TypeScript && !cfg.BundleEnabled() {
+			if err := node.CompileTypeScript(dir); err != nil {
+				return err
+			}
+		}
+
+		if !cfg.BundleEnabled() {
+			if err := node.PruneDevDependencies(dir, cfg.PackageManager, cfg.Offline); err != nil {
+				return err
+			}
+		}
+
+		for _, shim := range shims {
+			if err := node.WriteShim(dir, shim); err != nil {
+				return fmt.Errorf("failed to write %s shim: %v", shim, err)
+			}
+		}
+
+		if cfg.BundleEnabled() {
+			return node.Bundle(dir, node.BundleOptions{
+				Entrypoint: infra.GetHandlerFileName(cfg.Language),
+				Target:     node.RuntimeTarget(infra.GetPlatformRuntime(platform.AWS)),
+				Externals:  cfg.Bundle.Externals,
+			})
+		}
 	default:
 		return fmt.Errorf("unsupported language: %s", cfg.Language)
 	}
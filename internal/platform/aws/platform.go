@@ -5,10 +5,7 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/codeupify/upify/internal/config"
-	"github.com/codeupify/upify/internal/infra"
 	"github.com/codeupify/upify/internal/lang"
-	"github.com/codeupify/upify/internal/platform"
 )
 
 const pythonCode = `if os.getenv("UPIFY_DEPLOY_PLATFORM") == "aws-lambda":
@@ -24,36 +21,54 @@ const nodeCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === 'aws-lambda') {
       module.exports.handler = serverless(expressApp);
 }`
 
+const typescriptCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === 'aws-lambda') {
+    const serverless = require('serverless-http');
+    let expressApp = {APP_VAR};
+    if ({APP_VAR} && {APP_VAR}['app']) {
+        expressApp = {APP_VAR}['app'];
+    }
+    handler = serverless(expressApp);
+}`
+
+// HandlerCode returns the handler section for the language
+func HandlerCode(language lang.Language) (string, error) {
+	switch language {
+	case lang.Python:
+		return pythonCode, nil
+	case lang.JavaScript:
+		return nodeCode, nil
+	case lang.TypeScript:
+		return typescriptCode, nil
+	default:
+		return "", fmt.Errorf("unsupported language: %s", language)
+	}
+}
+
 //go:embed templates/main.tmpl
 var MainTemplate string
 
 //go:embed templates/main.module.tmpl
 var MainModuleTemplate string
 
-func AddPlatform(cfg *config.Config, region string, runtime string) error {
-	fmt.Println("Adding AWS handlers...")
+// Ingresses are the accepted ways for requests to reach the function
+var Ingresses = []string{"function_url", "apigateway"}
 
-	var handlerCode string
-	switch cfg.Language {
-	case lang.Python:
-		handlerCode = pythonCode
-	case lang.JavaScript, lang.TypeScript:
-		handlerCode = nodeCode
-	default:
-		return fmt.Errorf("unsupported language: %s", cfg.Language)
-	}
+// Ingress holds how requests reach the function, written to main.tf where it
+// can be changed later. The throttling limits only apply to API Gateway
+type Ingress struct {
+	Type                 string
+	ThrottlingBurstLimit int
+	ThrottlingRateLimit  float64
+}
 
-	err := infra.AddPlatformHandler(cfg, "aws-lambda", handlerCode)
-	if err != nil {
-		return err
+func (i Ingress) validate() error {
+	if i.ThrottlingBurstLimit < 0 || i.ThrottlingRateLimit < 0 {
+		return fmt.Errorf("throttling limits can't be negative")
 	}
-
-	fmt.Println("Setting up AWS Lambda infrastructure...")
-
-	mainContent := MainTemplate
-	mainContent = strings.Replace(mainContent, "{LAMBDA_NAME}", cfg.Name, -1)
-	mainContent = strings.Replace(mainContent, "{REGION}", region, -1)
-	mainContent = strings.Replace(mainContent, "{RUNTIME}", runtime, -1)
-
-	return infra.AddPlatform(platform.AWS, mainContent, MainModuleTemplate)
+	for _, ingress := range Ingresses {
+		if i.Type == ingress {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported ingress: %s (use %s)", i.Type, strings.Join(Ingresses, " or "))
 }
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"strconv"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/image"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/pflag"
+)
+
+type provider struct {
+	region  string
+	runtime string
+	ingress Ingress
+}
+
+func init() {
+	platform.Register(&provider{})
+}
+
+func (p *provider) Name() platform.Platform {
+	return platform.AWS
+}
+
+func (p *provider) Title() string {
+	return "AWS"
+}
+
+func (p *provider) Short() string {
+	return "Add AWS configuration"
+}
+
+func (p *provider) Flags(flags *pflag.FlagSet) {
+	flags.StringVar(&p.region, "region", "", "AWS region")
+	flags.StringVar(&p.runtime, "runtime", "", "Lambda runtime")
+	flags.StringVar(&p.ingress.Type, "ingress", "function_url", "How requests reach the function: function_url or apigateway")
+	flags.IntVar(&p.ingress.ThrottlingBurstLimit, "throttling-burst-limit", 1000, "API Gateway burst limit")
+	flags.Float64Var(&p.ingress.ThrottlingRateLimit, "throttling-rate-limit", 500, "API Gateway requests per second")
+}
+
+func (p *provider) Add(cfg *config.Config) error {
+	if err := platform.AskString(&p.region, "Enter AWS region:", "us-east-1"); err != nil {
+		return err
+	}
+
+	if err := platform.AskRuntime(&p.runtime, p, cfg.Language); err != nil {
+		return err
+	}
+
+	if err := p.ingress.validate(); err != nil {
+		return err
+	}
+
+	return infra.AddProvider(cfg, p, "aws-lambda", map[string]string{
+		"{LAMBDA_NAME}":            cfg.Name,
+		"{REGION}":                 p.region,
+		"{RUNTIME}":                p.runtime,
+		"{INGRESS}":                p.ingress.Type,
+		"{THROTTLING_BURST_LIMIT}": strconv.Itoa(p.ingress.ThrottlingBurstLimit),
+		"{THROTTLING_RATE_LIMIT}":  strconv.FormatFloat(p.ingress.ThrottlingRateLimit, 'f', -1, 64),
+	})
+}
+
+func (p *provider) Runtimes(language lang.Language) []string {
+	switch language {
+	case lang.Python:
+		return []string{"python3.8", "python3.9", "python3.10", "python3.11", "python3.12"}
+	case lang.JavaScript, lang.TypeScript:
+		return []string{"nodejs18.x", "nodejs20.x"}
+	default:
+		return []string{}
+	}
+}
+
+func (p *provider) HandlerCode(language lang.Language) (string, error) {
+	return HandlerCode(language)
+}
+
+func (p *provider) Templates() (string, string) {
+	return MainTemplate, MainModuleTemplate
+}
+
+func (p *provider) Stage(cfg *config.Config, dir string) error {
+	return Stage(cfg, dir)
+}
+
+func (p *provider) Deploy(cfg *config.Config, artifactPath string) error {
+	return Deploy(cfg, artifactPath)
+}
+
+func (p *provider) Destroy(cfg *config.Config) error {
+	return infra.Destroy(platform.AWS, map[string]string{"image_uri": ""}, "source_zip_path")
+}
+
+func (p *provider) Outputs() []string {
+	return []string{"lambda_function_url", "api_gateway_url"}
+}
+
+func (p *provider) ImageConfig(cfg *config.Config) image.RuntimeConfig {
+	return ImageConfig(cfg)
+}
@@ -0,0 +1,107 @@
+package awsecs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/image"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// Deploy builds the project into a container image, pushes it and applies
+// the platform's terraform configuration to run it on Fargate
+func Deploy(cfg *config.Config, artifactPath string) error {
+	if artifactPath != "" {
+		return fmt.Errorf("--artifact is not supported on ECS, which deploys the image built from the project")
+	}
+
+	if err := infra.PreDeployValidate(cfg, platform.AWSECS); err != nil {
+		return err
+	}
+
+	if err := infra.WriteEnvironmentVariables(platform.AWSECS); err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "ecs_deployment_")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	stagingDir := filepath.Join(tempDir, "source")
+	if err := Stage(cfg, stagingDir); err != nil {
+		return err
+	}
+
+	tarPath := filepath.Join(tempDir, "image.tar")
+	img, err := infra.CreateImage(cfg, platform.AWSECS, stagingDir, ImageConfig(cfg), filepath.Join(tempDir, "image"), tarPath)
+	if err != nil {
+		return err
+	}
+
+	imageRef, err := infra.PushImage(cfg, img)
+	if err != nil {
+		return err
+	}
+
+	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.AWSECS))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	vars := map[string]string{
+		"image_uri": imageRef,
+	}
+
+	ctx := context.Background()
+	if err := terraformManager.Apply(ctx, vars); err != nil {
+		return err
+	}
+
+	return infra.RecordDeploy(cfg, platform.AWSECS, tarPath, imageRef)
+}
+
+// ImageConfig serves the app on the PORT set in the task definition:
+// Python apps with gunicorn, Node.js apps by running upify_handler, which
+// starts their own server. The base image provides the language runtime
+func ImageConfig(cfg *config.Config) image.RuntimeConfig {
+	rc := image.RuntimeConfig{
+		AppDir:     "/app",
+		WorkingDir: "/app",
+		Entrypoint: infra.ServerCommand(cfg),
+		Cmd:        []string{},
+	}
+
+	if cfg.Language == lang.Python {
+		rc.Env = map[string]string{"PYTHONPATH": "/app"}
+	}
+
+	return rc
+}
+
+// Stage copies the project into dir and installs its dependencies, leaving
+// dir ready to be layered on the base image. ECS only runs images, so
+// package_type has to be image
+func Stage(cfg *config.Config, dir string) error {
+	if !cfg.ImagePackaging() {
+		return fmt.Errorf("ECS runs container images, set package_type: image and image.base in .upify/config.yaml")
+	}
+
+	err := infra.CopySource(cfg, dir)
+	if err != nil {
+		return err
+	}
+
+	err = infra.InstallRequirements(cfg, platform.AWSECS, dir)
+	if err != nil {
+		return fmt.Errorf("failed to install requirements: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package awsecs
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// HandlerCode returns the handler section for the language
+func HandlerCode(language lang.Language) (string, error) {
+	return infra.ServerHandlerCode(platform.AWSECS, language)
+}
+
+//go:embed templates/main.tmpl
+var MainTemplate string
+
+//go:embed templates/main.module.tmpl
+var MainModuleTemplate string
+
+// fargateCPU are the CPU units a Fargate task can have
+var fargateCPU = []int{256, 512, 1024, 2048, 4096, 8192, 16384}
+
+// Service holds the task size, autoscaling range and health check written to
+// main.tf, where they can be changed later
+type Service struct {
+	CPU             int
+	Memory          int
+	MinInstances    int
+	MaxInstances    int
+	HealthCheckPath string
+}
+
+func (s Service) validate() error {
+	validCPU := false
+	for _, cpu := range fargateCPU {
+		if s.CPU == cpu {
+			validCPU = true
+		}
+	}
+	if !validCPU {
+		return fmt.Errorf("unsupported cpu: %d (use 256, 512, 1024, 2048, 4096, 8192 or 16384)", s.CPU)
+	}
+	if s.Memory < 512 {
+		return fmt.Errorf("memory must be at least 512 MiB, got %d", s.Memory)
+	}
+	if s.MinInstances < 1 || s.MinInstances > s.MaxInstances {
+		return fmt.Errorf("invalid instance range %d-%d", s.MinInstances, s.MaxInstances)
+	}
+	if !strings.HasPrefix(s.HealthCheckPath, "/") {
+		return fmt.Errorf("health check path must start with /, got %q", s.HealthCheckPath)
+	}
+	return nil
+}
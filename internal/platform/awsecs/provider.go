@@ -0,0 +1,107 @@
+package awsecs
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/image"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/pflag"
+)
+
+type provider struct {
+	region  string
+	runtime string
+	service Service
+}
+
+func init() {
+	platform.Register(&provider{})
+}
+
+func (p *provider) Name() platform.Platform {
+	return platform.AWSECS
+}
+
+func (p *provider) Title() string {
+	return "AWS ECS"
+}
+
+func (p *provider) Short() string {
+	return "Add AWS ECS Fargate configuration"
+}
+
+func (p *provider) Flags(flags *pflag.FlagSet) {
+	flags.StringVar(&p.region, "region", "", "AWS region")
+	flags.StringVar(&p.runtime, "runtime", "", "Language runtime")
+	flags.IntVar(&p.service.CPU, "cpu", 256, "CPU units per task")
+	flags.IntVar(&p.service.Memory, "memory", 512, "Memory per task in MiB")
+	flags.IntVar(&p.service.MinInstances, "min-instances", 1, "Minimum number of tasks")
+	flags.IntVar(&p.service.MaxInstances, "max-instances", 4, "Maximum number of tasks")
+	flags.StringVar(&p.service.HealthCheckPath, "health-check-path", "/", "Path the load balancer checks")
+}
+
+func (p *provider) Add(cfg *config.Config) error {
+	if err := platform.AskString(&p.region, "Enter AWS region:", "us-east-1"); err != nil {
+		return err
+	}
+
+	if err := platform.AskRuntime(&p.runtime, p, cfg.Language); err != nil {
+		return err
+	}
+
+	if err := p.service.validate(); err != nil {
+		return err
+	}
+
+	// The load balancer and target group names are limited to 32 characters
+	if len(cfg.Name) > 32 {
+		return fmt.Errorf("project name %q is too long for ECS, which allows 32 characters", cfg.Name)
+	}
+
+	return infra.AddProvider(cfg, p, "aws-ecs", map[string]string{
+		"{SERVICE_NAME}":      cfg.Name,
+		"{REGION}":            p.region,
+		"{RUNTIME}":           p.runtime,
+		"{CPU}":               strconv.Itoa(p.service.CPU),
+		"{MEMORY}":            strconv.Itoa(p.service.Memory),
+		"{MIN_INSTANCES}":     strconv.Itoa(p.service.MinInstances),
+		"{MAX_INSTANCES}":     strconv.Itoa(p.service.MaxInstances),
+		"{HEALTH_CHECK_PATH}": p.service.HealthCheckPath,
+	})
+}
+
+func (p *provider) Runtimes(language lang.Language) []string {
+	return platform.ContainerRuntimes(language)
+}
+
+func (p *provider) HandlerCode(language lang.Language) (string, error) {
+	return HandlerCode(language)
+}
+
+func (p *provider) Templates() (string, string) {
+	return MainTemplate, MainModuleTemplate
+}
+
+func (p *provider) Stage(cfg *config.Config, dir string) error {
+	return Stage(cfg, dir)
+}
+
+func (p *provider) Deploy(cfg *config.Config, artifactPath string) error {
+	return Deploy(cfg, artifactPath)
+}
+
+func (p *provider) Destroy(cfg *config.Config) error {
+	return infra.Destroy(platform.AWSECS, map[string]string{"image_uri": ""})
+}
+
+func (p *provider) Outputs() []string {
+	return []string{"service_url"}
+}
+
+func (p *provider) ImageConfig(cfg *config.Config) image.RuntimeConfig {
+	return ImageConfig(cfg)
+}
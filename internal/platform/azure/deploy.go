@@ -0,0 +1,157 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// functionName is the directory holding the function.json that routes every
+// request to upify_handler
+const functionName = "upify"
+
+// Deploy applies the platform's terraform configuration. When artifactPath is
+// empty the project is staged and zipped first, otherwise the prebuilt
+// artifact is deployed as is
+func Deploy(cfg *config.Config, artifactPath string) error {
+	if cfg.ImagePackaging() {
+		return fmt.Errorf("package_type image is not supported on Azure yet")
+	}
+
+	if artifactPath == "" {
+		if err := infra.PreDeployValidate(cfg, platform.Azure); err != nil {
+			return err
+		}
+	} else if err := infra.ValidateTerraformDir(platform.Azure); err != nil {
+		return err
+	}
+
+	if err := infra.WriteEnvironmentVariables(platform.Azure); err != nil {
+		return err
+	}
+
+	if artifactPath == "" {
+		tempDir, err := os.MkdirTemp("", "azure_deployment_")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		stagingDir := filepath.Join(tempDir, "source")
+		if err := Stage(cfg, stagingDir); err != nil {
+			return err
+		}
+
+		artifactPath = filepath.Join(tempDir, "source.zip")
+		if err := infra.CreateArtifact(cfg, platform.Azure, stagingDir, artifactPath); err != nil {
+			return err
+		}
+	}
+
+	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.Azure))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	vars := map[string]string{
+		"source_zip_path": artifactPath,
+	}
+
+	ctx := context.Background()
+	if err := terraformManager.Apply(ctx, vars); err != nil {
+		return err
+	}
+
+	return infra.RecordDeploy(cfg, platform.Azure, artifactPath, "")
+}
+
+// Stage copies the project into dir, installs its dependencies along with the
+// Azure Functions adapters and writes the host.json and function.json files
+// the Functions host loads, leaving dir ready to be zipped. Dependencies are
+// shipped in the package since run-from-package apps aren't built remotely.
+// The Python worker puts the app root on sys.path, so packages are installed
+// next to the handler like on Lambda. Adapters aren't shimmed, since the
+// serverless-http shim only understands Lambda events
+func Stage(cfg *config.Config, dir string) error {
+	err := infra.CopySource(cfg, dir)
+	if err != nil {
+		return err
+	}
+
+	err = infra.InstallRequirements(cfg, platform.Azure, dir)
+	if err != nil {
+		return fmt.Errorf("failed to install requirements: %v", err)
+	}
+
+	err = writeFunctionMetadata(dir, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to write function metadata: %v", err)
+	}
+
+	return nil
+}
+
+// writeFunctionMetadata declares a single HTTP function catching every route
+// and method, with the /api route prefix removed so the app sees the same
+// paths as on other platforms
+func writeFunctionMetadata(dir string, cfg *config.Config) error {
+	host := map[string]interface{}{
+		"version": "2.0",
+		"extensions": map[string]interface{}{
+			"http": map[string]interface{}{
+				"routePrefix": "",
+			},
+		},
+	}
+
+	scriptFile := "upify_handler.js"
+	if cfg.Language == lang.Python {
+		scriptFile = "upify_handler.py"
+	}
+
+	function := map[string]interface{}{
+		"scriptFile": "../" + scriptFile,
+		"entryPoint": "handler",
+		"bindings": []map[string]interface{}{
+			{
+				"authLevel": "anonymous",
+				"type":      "httpTrigger",
+				"direction": "in",
+				"name":      "req",
+				"methods":   []string{"get", "post", "put", "patch", "delete", "head", "options"},
+				"route":     "{*route}",
+			},
+			{
+				"type":      "http",
+				"direction": "out",
+				"name":      "$return",
+			},
+		},
+	}
+
+	if err := writeJSON(filepath.Join(dir, "host.json"), host); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, functionName), 0755); err != nil {
+		return err
+	}
+
+	return writeJSON(filepath.Join(dir, functionName, "function.json"), function)
+}
+
+func writeJSON(path string, value interface{}) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
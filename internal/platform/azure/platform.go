@@ -0,0 +1,56 @@
+package azure
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/codeupify/upify/internal/lang"
+)
+
+const pythonCode = `if os.getenv("UPIFY_DEPLOY_PLATFORM") == "azure-functions":
+    import azure.functions as func
+
+    wsgi_middleware = func.WsgiMiddleware(app.wsgi_app)
+
+    def azure_function(req: func.HttpRequest, context: func.Context) -> func.HttpResponse:
+        return wsgi_middleware.handle(req, context)
+
+    handler = azure_function`
+
+const nodeCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === 'azure-functions') {
+    const serverless = require('serverless-http');
+    let expressApp = {APP_VAR};
+    if ({APP_VAR} && {APP_VAR}['app']) {
+        expressApp = {APP_VAR}['app'];
+    }
+    module.exports.handler = serverless(expressApp, { provider: 'azure' });
+}`
+
+const typescriptCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === 'azure-functions') {
+    const serverless = require('serverless-http');
+    let expressApp = {APP_VAR};
+    if ({APP_VAR} && {APP_VAR}['app']) {
+        expressApp = {APP_VAR}['app'];
+    }
+    handler = serverless(expressApp, { provider: 'azure' });
+}`
+
+// HandlerCode returns the handler section for the language
+func HandlerCode(language lang.Language) (string, error) {
+	switch language {
+	case lang.Python:
+		return pythonCode, nil
+	case lang.JavaScript:
+		return nodeCode, nil
+	case lang.TypeScript:
+		return typescriptCode, nil
+	default:
+		return "", fmt.Errorf("unsupported language: %s", language)
+	}
+}
+
+//go:embed templates/main.tmpl
+var MainTemplate string
+
+//go:embed templates/main.module.tmpl
+var MainModuleTemplate string
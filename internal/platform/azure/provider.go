@@ -0,0 +1,93 @@
+package azure
+
+import (
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/pflag"
+)
+
+type provider struct {
+	location       string
+	subscriptionId string
+	runtime        string
+}
+
+func init() {
+	platform.Register(&provider{})
+}
+
+func (p *provider) Name() platform.Platform {
+	return platform.Azure
+}
+
+func (p *provider) Title() string {
+	return "Azure"
+}
+
+func (p *provider) Short() string {
+	return "Add Azure configuration"
+}
+
+func (p *provider) Flags(flags *pflag.FlagSet) {
+	flags.StringVar(&p.location, "location", "", "Azure region")
+	flags.StringVar(&p.subscriptionId, "subscription-id", "", "Azure subscription ID")
+	flags.StringVar(&p.runtime, "runtime", "", "Azure Functions runtime")
+}
+
+func (p *provider) Add(cfg *config.Config) error {
+	if err := platform.AskString(&p.location, "Enter Azure region:", "eastus"); err != nil {
+		return err
+	}
+
+	if err := platform.AskString(&p.subscriptionId, "Enter Azure subscription ID:", ""); err != nil {
+		return err
+	}
+
+	if err := platform.AskRuntime(&p.runtime, p, cfg.Language); err != nil {
+		return err
+	}
+
+	return infra.AddProvider(cfg, p, "azure-functions", map[string]string{
+		"{FUNCTION_NAME}":   cfg.Name,
+		"{LOCATION}":        p.location,
+		"{RUNTIME}":         p.runtime,
+		"{SUBSCRIPTION_ID}": p.subscriptionId,
+	})
+}
+
+func (p *provider) Runtimes(language lang.Language) []string {
+	switch language {
+	case lang.Python:
+		return []string{"python3.9", "python3.10", "python3.11", "python3.12"}
+	case lang.JavaScript, lang.TypeScript:
+		return []string{"node18", "node20", "node22"}
+	default:
+		return []string{}
+	}
+}
+
+func (p *provider) HandlerCode(language lang.Language) (string, error) {
+	return HandlerCode(language)
+}
+
+func (p *provider) Templates() (string, string) {
+	return MainTemplate, MainModuleTemplate
+}
+
+func (p *provider) Stage(cfg *config.Config, dir string) error {
+	return Stage(cfg, dir)
+}
+
+func (p *provider) Deploy(cfg *config.Config, artifactPath string) error {
+	return Deploy(cfg, artifactPath)
+}
+
+func (p *provider) Destroy(cfg *config.Config) error {
+	return infra.Destroy(platform.Azure, nil, "source_zip_path")
+}
+
+func (p *provider) Outputs() []string {
+	return []string{"function_app_url"}
+}
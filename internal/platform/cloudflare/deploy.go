@@ -0,0 +1,200 @@
+package cloudflare
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/lang/node"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/codeupify/upify/internal/platform/adapters"
+)
+
+// workerScript is the bundled module uploaded as the worker
+const workerScript = "upify_worker.js"
+
+// workerEntry is the ES module the bundle starts from. Express servers are
+// started inside the worker and requests are forwarded to them by
+// httpServerHandler
+const workerEntry = `import { httpServerHandler } from 'cloudflare:node';
+import * as upify from './upify_handler';
+
+const port = 8080;
+
+let worker = upify.handler;
+if (!worker) {
+    upify.server.listen(port);
+    worker = httpServerHandler({ port });
+}
+
+export default worker;
+`
+
+// Deploy applies the platform's terraform configuration. When artifactPath is
+// empty the project is staged and zipped first, otherwise the prebuilt
+// artifact is deployed as is. Either way the worker script is taken from the
+// zip, so the uploaded bytes are the packaged ones
+func Deploy(cfg *config.Config, artifactPath string) error {
+	if cfg.ImagePackaging() {
+		return fmt.Errorf("package_type image is not supported on Cloudflare Workers")
+	}
+
+	if artifactPath == "" {
+		if err := infra.PreDeployValidate(cfg, platform.Cloudflare); err != nil {
+			return err
+		}
+	} else if err := infra.ValidateTerraformDir(platform.Cloudflare); err != nil {
+		return err
+	}
+
+	if err := infra.WriteEnvironmentVariables(platform.Cloudflare); err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "cloudflare_deployment_")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if artifactPath == "" {
+		stagingDir := filepath.Join(tempDir, "source")
+		if err := Stage(cfg, stagingDir); err != nil {
+			return err
+		}
+
+		artifactPath = filepath.Join(tempDir, "source.zip")
+		if err := infra.CreateArtifact(cfg, platform.Cloudflare, stagingDir, artifactPath); err != nil {
+			return err
+		}
+	}
+
+	scriptPath := filepath.Join(tempDir, workerScript)
+	if err := extractWorker(artifactPath, scriptPath); err != nil {
+		return err
+	}
+
+	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.Cloudflare))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	vars := map[string]string{
+		"script_path": scriptPath,
+	}
+
+	ctx := context.Background()
+	if err := terraformManager.Apply(ctx, vars); err != nil {
+		return err
+	}
+
+	return infra.RecordDeploy(cfg, platform.Cloudflare, artifactPath, "")
+}
+
+// Stage copies the project into dir, installs its dependencies along with the
+// adapters and bundles it into a single ES module for the Workers runtime,
+// whatever the bundle setting, since workers can't load node_modules
+func Stage(cfg *config.Config, dir string) error {
+	if cfg.Language != lang.JavaScript && cfg.Language != lang.TypeScript {
+		return fmt.Errorf("Cloudflare Workers only supports JavaScript and TypeScript projects")
+	}
+
+	err := infra.CopySource(cfg, dir)
+	if err != nil {
+		return err
+	}
+
+	if err := node.InstallPackagesJSON(dir, cfg.PackageManager, cfg.Offline); err != nil {
+		return fmt.Errorf("failed to install requirements: %v", err)
+	}
+
+	var results []adapters.Result
+	for _, adapter := range adapters.For(platform.Cloudflare, cfg) {
+		if cfg.Offline {
+			if installed := node.InstalledVersion(dir, adapter.Package); installed != "" {
+				results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed})
+				continue
+			}
+			return adapters.OfflineError(adapter)
+		}
+
+		installed, added, err := node.InstallPackage(dir, adapter.Package, adapter.Version, cfg.PackageManager)
+		if err != nil {
+			return err
+		}
+		results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed, Added: added})
+	}
+	adapters.PrintReport(results)
+
+	// TypeScript is compiled by esbuild as part of the bundle
+	if cfg.Language == lang.JavaScript {
+		pkgJson, err := node.ParsePackageJSON(filepath.Join(dir, "package.json"))
+		if err != nil {
+			return fmt.Errorf("failed to parse package.json: %v", err)
+		}
+
+		if err := node.Build(dir, pkgJson, cfg.PackageManager); err != nil {
+			return err
+		}
+	}
+
+	nativeModules, err := node.FindNativeModules(dir)
+	if err != nil {
+		return fmt.Errorf("failed to detect native modules: %v", err)
+	}
+	if len(nativeModules) > 0 {
+		return fmt.Errorf("native modules can't run on Cloudflare Workers: %s", strings.Join(nativeModules, ", "))
+	}
+
+	entry := strings.TrimSuffix(workerScript, ".js") + ".mjs"
+	if err := os.WriteFile(filepath.Join(dir, entry), []byte(workerEntry), 0644); err != nil {
+		return fmt.Errorf("failed to write worker entry: %v", err)
+	}
+
+	return node.Bundle(dir, node.BundleOptions{
+		Entrypoint: entry,
+		Target:     "es2022",
+		Workers:    true,
+	})
+}
+
+// extractWorker copies the worker script out of the artifact
+func extractWorker(zipPath string, dest string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to open artifact: %v", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if strings.TrimPrefix(file.Name, "./") != workerScript {
+			continue
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		out, err := os.Create(dest)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		if _, err := io.Copy(out, src); err != nil {
+			return fmt.Errorf("failed to extract %s: %v", workerScript, err)
+		}
+		return out.Close()
+	}
+
+	return fmt.Errorf("%s has no %s; package it with `upify package cloudflare`", zipPath, workerScript)
+}
@@ -0,0 +1,66 @@
+package cloudflare
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+
+	"github.com/codeupify/upify/internal/lang"
+)
+
+// The app is exported as the worker when it's already a fetch-style handler
+// (an object with a fetch method), otherwise it's handed to the worker entry
+// as an Express server
+const nodeCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === 'cloudflare') {
+    let worker = {APP_VAR};
+    if (worker && worker['default']) {
+        worker = worker['default'];
+    }
+    if (worker && typeof worker.fetch === 'function') {
+        module.exports.handler = worker;
+    } else {
+        module.exports.server = worker && worker['app'] ? worker['app'] : worker;
+    }
+}`
+
+const typescriptCode = `export let server: any = undefined;
+
+if (process.env.UPIFY_DEPLOY_PLATFORM === 'cloudflare') {
+    let worker: any = {APP_VAR};
+    if (worker && worker['default']) {
+        worker = worker['default'];
+    }
+    if (worker && typeof worker.fetch === 'function') {
+        handler = worker;
+    } else {
+        server = worker && worker['app'] ? worker['app'] : worker;
+    }
+}`
+
+// HandlerCode returns the handler section for the language
+func HandlerCode(language lang.Language) (string, error) {
+	switch language {
+	case lang.JavaScript:
+		return nodeCode, nil
+	case lang.TypeScript:
+		return typescriptCode, nil
+	default:
+		return "", fmt.Errorf("Cloudflare Workers only supports JavaScript and TypeScript projects")
+	}
+}
+
+//go:embed templates/main.tmpl
+var MainTemplate string
+
+//go:embed templates/main.module.tmpl
+var MainModuleTemplate string
+
+// workerName is the format of worker script names
+var workerName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// Route sends requests matching Pattern in the zone to the worker. With no
+// route the worker is only served on workers.dev
+type Route struct {
+	ZoneId  string
+	Pattern string
+}
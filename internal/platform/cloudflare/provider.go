@@ -0,0 +1,95 @@
+package cloudflare
+
+import (
+	"fmt"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/pflag"
+)
+
+type provider struct {
+	accountId        string
+	workersSubdomain string
+	route            Route
+}
+
+func init() {
+	platform.Register(&provider{})
+}
+
+func (p *provider) Name() platform.Platform {
+	return platform.Cloudflare
+}
+
+func (p *provider) Title() string {
+	return "Cloudflare Workers"
+}
+
+func (p *provider) Short() string {
+	return "Add Cloudflare Workers configuration"
+}
+
+func (p *provider) Flags(flags *pflag.FlagSet) {
+	flags.StringVar(&p.accountId, "account-id", "", "Cloudflare account ID")
+	flags.StringVar(&p.workersSubdomain, "workers-subdomain", "", "The account's workers.dev subdomain, for the worker URL")
+	flags.StringVar(&p.route.Pattern, "route", "", "Route pattern sending requests to the worker (e.g. api.example.com/*)")
+	flags.StringVar(&p.route.ZoneId, "zone-id", "", "Zone ID of the route")
+}
+
+func (p *provider) Add(cfg *config.Config) error {
+	if err := platform.AskString(&p.accountId, "Enter Cloudflare account ID:", ""); err != nil {
+		return err
+	}
+
+	if cfg.Language != lang.JavaScript && cfg.Language != lang.TypeScript {
+		return fmt.Errorf("Cloudflare Workers only supports JavaScript and TypeScript projects")
+	}
+
+	if !workerName.MatchString(cfg.Name) {
+		return fmt.Errorf("project name %q isn't a valid worker name (lowercase letters, digits and -)", cfg.Name)
+	}
+
+	if (p.route.Pattern == "") != (p.route.ZoneId == "") {
+		return fmt.Errorf("a route needs both --route and --zone-id")
+	}
+
+	return infra.AddProvider(cfg, p, "cloudflare", map[string]string{
+		"{WORKER_NAME}":       cfg.Name,
+		"{ACCOUNT_ID}":        p.accountId,
+		"{WORKERS_SUBDOMAIN}": p.workersSubdomain,
+		"{ZONE_ID}":           p.route.ZoneId,
+		"{ROUTE_PATTERN}":     p.route.Pattern,
+	})
+}
+
+func (p *provider) Runtimes(language lang.Language) []string {
+	// Workers have a single runtime
+	return []string{}
+}
+
+func (p *provider) HandlerCode(language lang.Language) (string, error) {
+	return HandlerCode(language)
+}
+
+func (p *provider) Templates() (string, string) {
+	return MainTemplate, MainModuleTemplate
+}
+
+func (p *provider) Stage(cfg *config.Config, dir string) error {
+	return Stage(cfg, dir)
+}
+
+func (p *provider) Deploy(cfg *config.Config, artifactPath string) error {
+	return Deploy(cfg, artifactPath)
+}
+
+func (p *provider) Destroy(cfg *config.Config) error {
+	return infra.Destroy(platform.Cloudflare, nil, "script_path")
+}
+
+func (p *provider) Outputs() []string {
+	return []string{"worker_url"}
+}
@@ -3,11 +3,13 @@ package platform
 type Platform string
 
 const (
-	AWS Platform = "aws"
-	GCP Platform = "gcp"
-)
+	AWS    Platform = "aws"
+	AWSECS Platform = "aws-ecs"
+	GCP    Platform = "gcp"
+	GCPRun Platform = "gcp-run"
+	Azure  Platform = "azure"
+	K8s    Platform = "k8s"
 
-var AllPlatforms = []Platform{
-	AWS,
-	GCP,
-}
+	Cloudflare Platform = "cloudflare"
+	SelfHost   Platform = "selfhost"
+)
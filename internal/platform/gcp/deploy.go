@@ -8,18 +8,87 @@ import (
 	"regexp"
 
 	"github.com/codeupify/upify/internal/config"
-	"github.com/codeupify/upify/internal/fs"
+	"github.com/codeupify/upify/internal/image"
 	"github.com/codeupify/upify/internal/infra"
 	"github.com/codeupify/upify/internal/lang"
 	"github.com/codeupify/upify/internal/lang/node"
+	"github.com/codeupify/upify/internal/lang/python"
 	"github.com/codeupify/upify/internal/platform"
+	"github.com/codeupify/upify/internal/platform/adapters"
 )
 
-func Deploy(cfg *config.Config) error {
+const functionsFramework = "@google-cloud/functions-framework"
+
+// Deploy applies the platform's terraform configuration. When artifactPath is
+// empty the project is staged and zipped first, otherwise the prebuilt
+// artifact is deployed as is
+func Deploy(cfg *config.Config, artifactPath string) error {
+	if cfg.ImagePackaging() {
+		return deployImage(cfg, artifactPath)
+	}
+
+	if artifactPath == "" {
+		if err := infra.PreDeployValidate(cfg, platform.GCP); err != nil {
+			return err
+		}
+	} else if err := infra.ValidateTerraformDir(platform.GCP); err != nil {
+		return err
+	}
+
+	if err := infra.WriteEnvironmentVariables(platform.GCP); err != nil {
+		return err
+	}
+
+	if artifactPath == "" {
+		tempDir, err := os.MkdirTemp("", "cloudrun_deployment_")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		stagingDir := filepath.Join(tempDir, "source")
+		if err := Stage(cfg, stagingDir); err != nil {
+			return err
+		}
+
+		artifactPath = filepath.Join(tempDir, "source.zip")
+		if err := infra.CreateArtifact(cfg, platform.GCP, stagingDir, artifactPath); err != nil {
+			return err
+		}
+	}
+
+	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.GCP))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	vars := map[string]string{
+		"source_zip_path": artifactPath,
+	}
+
+	ctx := context.Background()
+	if err := terraformManager.Apply(ctx, vars); err != nil {
+		return err
+	}
+
+	return infra.RecordDeploy(cfg, platform.GCP, artifactPath, "")
+}
+
+// deployImage builds the project into a container image that runs the
+// functions framework, pushes it and deploys it as a Cloud Run service
+func deployImage(cfg *config.Config, artifactPath string) error {
+	if artifactPath != "" {
+		return fmt.Errorf("--artifact is only supported when package_type is zip")
+	}
+
 	if err := infra.PreDeployValidate(cfg, platform.GCP); err != nil {
 		return err
 	}
 
+	if err := infra.ValidateImageTemplate(platform.GCP); err != nil {
+		return err
+	}
+
 	if err := infra.WriteEnvironmentVariables(platform.GCP); err != nil {
 		return err
 	}
@@ -30,28 +99,20 @@ func Deploy(cfg *config.Config) error {
 	}
 	defer os.RemoveAll(tempDir)
 
-	err = fs.CopyFilesToTempDir(".", tempDir)
-	if err != nil {
-		return fmt.Errorf("failed to copy files to temp directory: %v", err)
+	stagingDir := filepath.Join(tempDir, "source")
+	if err := Stage(cfg, stagingDir); err != nil {
+		return err
 	}
 
-	err = adjustEntryPointFile(cfg, tempDir)
+	tarPath := filepath.Join(tempDir, "image.tar")
+	img, err := infra.CreateImage(cfg, platform.GCP, stagingDir, ImageConfig(cfg), filepath.Join(tempDir, "image"), tarPath)
 	if err != nil {
-		return fmt.Errorf("failed to adjust entrypoint file: %v", err)
-	}
-
-	if cfg.Language == lang.JavaScript || cfg.Language == lang.TypeScript {
-		err = updatePackageJson(cfg, tempDir)
-		if err != nil {
-			return fmt.Errorf("failed to update package.json: %v", err)
-		}
+		return err
 	}
 
-	zipPath := filepath.Join(tempDir, "source.zip")
-	fmt.Printf("Creating %s...\n", zipPath)
-	err = fs.CreateZip(tempDir, zipPath)
+	imageRef, err := infra.PushImage(cfg, img)
 	if err != nil {
-		return fmt.Errorf("failed to create zip: %v", err)
+		return err
 	}
 
 	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.GCP))
@@ -60,7 +121,8 @@ func Deploy(cfg *config.Config) error {
 	}
 
 	vars := map[string]string{
-		"source_zip_path": zipPath,
+		"source_zip_path": "",
+		"image_uri":       imageRef,
 	}
 
 	ctx := context.Background()
@@ -68,9 +130,147 @@ func Deploy(cfg *config.Config) error {
 		return err
 	}
 
+	return infra.RecordDeploy(cfg, platform.GCP, tarPath, imageRef)
+}
+
+// ImageConfig starts the functions framework on the handler, which listens
+// on the PORT Cloud Run sets. The base image provides the language runtime
+func ImageConfig(cfg *config.Config) image.RuntimeConfig {
+	rc := image.RuntimeConfig{
+		AppDir:     "/app",
+		WorkingDir: "/app",
+		Cmd:        []string{},
+	}
+
+	switch cfg.Language {
+	case lang.Python:
+		rc.Entrypoint = []string{"python3", "-m", "functions_framework", "--target=handler", "--source=main.py"}
+		rc.Env = map[string]string{"PYTHONPATH": "/app"}
+	default:
+		rc.Entrypoint = []string{"node", "node_modules/" + functionsFramework + "/build/src/main.js", "--target=handler"}
+	}
+
+	return rc
+}
+
+// Stage copies the project into dir and rewrites the entrypoint and
+// package.json the way Cloud Functions expects, leaving dir ready to be zipped.
+// Dependencies are installed by the Cloud Functions build, not locally,
+// except for image packages which have to run as built
+func Stage(cfg *config.Config, dir string) error {
+	err := infra.CopySource(cfg, dir)
+	if err != nil {
+		return err
+	}
+
+	err = adjustEntryPointFile(cfg, dir)
+	if err != nil {
+		return fmt.Errorf("failed to adjust entrypoint file: %v", err)
+	}
+
+	if cfg.Language == lang.Python {
+		// Cloud Functions installs Python dependencies from requirements.txt
+		if _, err := python.WriteRequirements(dir, cfg.GetSourceDir(), cfg.PackageManager); err != nil {
+			return fmt.Errorf("failed to write requirements.txt: %v", err)
+		}
+
+		var results []adapters.Result
+		for _, adapter := range adapters.For(platform.GCP, cfg) {
+			declared, added, err := python.AddRequirement(dir, adapter.Package, adapter.Version)
+			if err != nil {
+				return fmt.Errorf("failed to update requirements.txt: %v", err)
+			}
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: declared, Added: added})
+		}
+		adapters.PrintReport(results)
+	}
+
+	if (cfg.Language == lang.JavaScript || cfg.Language == lang.TypeScript) && cfg.BundleEnabled() {
+		err = bundleNodeProject(cfg, dir)
+		if err != nil {
+			return fmt.Errorf("failed to bundle project: %v", err)
+		}
+	} else if cfg.Language == lang.JavaScript || cfg.Language == lang.TypeScript {
+		err = updatePackageJson(cfg, dir)
+		if err != nil {
+			return fmt.Errorf("failed to update package.json: %v", err)
+		}
+	}
+
+	if cfg.ImagePackaging() {
+		err = installForImage(cfg, dir)
+		if err != nil {
+			return fmt.Errorf("failed to install requirements: %v", err)
+		}
+	}
+
 	return nil
 }
 
+// installForImage does what the Cloud Functions build would: install the
+// dependencies declared in the staged requirements.txt or package.json and
+// run the build
+func installForImage(cfg *config.Config, dir string) error {
+	switch cfg.Language {
+	case lang.Python:
+		py, err := python.FindInterpreter(cfg.GetSourceDir(), cfg.PythonInterpreter())
+		if err != nil {
+			return err
+		}
+
+		if err := py.CheckRuntime(infra.GetPlatformRuntime(platform.GCP)); err != nil {
+			return err
+		}
+
+		if err := py.CheckPip(); err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "requirements.txt")); os.IsNotExist(err) {
+			return nil
+		}
+
+		fmt.Printf("Installing requirements with %s (Python %s)...\n", py.Path, py.Version)
+		if err := py.Pip(dir, "install", "-r", "requirements.txt", "-t", dir); err != nil {
+			return fmt.Errorf("failed to install Python requirements: %v", err)
+		}
+
+		return nil
+
+	case lang.JavaScript, lang.TypeScript:
+		pkgJson, err := node.ParsePackageJSON(filepath.Join(dir, "package.json"))
+		if err != nil {
+			return fmt.Errorf("failed to parse package.json: %v", err)
+		}
+
+		// The bundle only leaves the functions framework to install, the
+		// other externals were copied along with it
+		if cfg.BundleEnabled() {
+			_, _, err := node.InstallPackage(dir, functionsFramework, pkgJson.Dependencies[functionsFramework], cfg.PackageManager)
+			return err
+		}
+
+		if err := node.InstallPackagesJSON(dir, cfg.PackageManager, cfg.Offline); err != nil {
+			return err
+		}
+
+		if err := node.Build(dir, pkgJson, cfg.PackageManager); err != nil {
+			return err
+		}
+
+		if cfg.Language == lang.TypeScript {
+			if err := node.CompileTypeScript(dir); err != nil {
+				return err
+			}
+		}
+
+		return node.PruneDevDependencies(dir, cfg.PackageManager, cfg.Offline)
+
+	default:
+		return fmt.Errorf("unsupported language: %s", cfg.Language)
+	}
+}
+
 func updatePackageJson(cfg *config.Config, tempDirPath string) error {
 	pkgJson, err := node.ParsePackageJSON(filepath.Join(tempDirPath, "package.json"))
 	if err != nil {
@@ -79,19 +279,120 @@ func updatePackageJson(cfg *config.Config, tempDirPath string) error {
 
 	node.SetMainInPackageJSON(pkgJson, "upify_handler.js")
 
-	node.AddPackageToPackageJSON(pkgJson, "@google-cloud/functions-framework", "^3.0.0")
-	if pkgJson.Scripts != nil && pkgJson.Scripts["build"] != "" {
-		buildCommand := "npm run build"
-		if cfg.PackageManager == lang.Yarn {
-			buildCommand = "yarn build"
+	// Cloud Functions installs dependencies itself, so adapters are declared
+	// in package.json rather than installed
+	var results []adapters.Result
+	for _, adapter := range adapters.For(platform.GCP, cfg) {
+		if declared, ok := pkgJson.Dependencies[adapter.Package]; ok {
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: declared})
+			continue
 		}
 
-		node.AddScriptToPackageJSON(pkgJson, "gcp-build", buildCommand)
+		// Updating the lockfile would need the registry
+		if cfg.Offline && node.HasLockfile(tempDirPath, cfg.PackageManager) {
+			return adapters.OfflineError(adapter)
+		}
+
+		node.AddPackageToPackageJSON(pkgJson, adapter.Package, adapter.Version)
+		if err := node.UpdateLockfile(tempDirPath, adapter.Package, adapter.Version, cfg.PackageManager); err != nil {
+			return err
+		}
+		results = append(results, adapters.Result{Adapter: adapter, Added: true})
+	}
+	adapters.PrintReport(results)
+
+	if cfg.Language == lang.TypeScript {
+		compileCommand, err := node.PrepareTypeScript(tempDirPath)
+		if err != nil {
+			return err
+		}
+
+		node.AddScriptToPackageJSON(pkgJson, "gcp-build", compileCommand)
+	} else if pkgJson.Scripts != nil && pkgJson.Scripts["build"] != "" {
+		node.AddScriptToPackageJSON(pkgJson, "gcp-build", node.BuildpackScriptCommand(cfg.PackageManager, "build"))
 	}
 
 	return node.WritePackageJSON(filepath.Join(tempDirPath, "package.json"), pkgJson)
 }
 
+// bundleNodeProject installs and bundles the project locally, then writes a
+// package.json that only lists the functions framework and the packages left
+// external, since those are all Cloud Functions needs to install
+func bundleNodeProject(cfg *config.Config, dir string) error {
+	pkgJsonPath := filepath.Join(dir, "package.json")
+	pkgJson, err := node.ParsePackageJSON(pkgJsonPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse package.json: %v", err)
+	}
+
+	if err := node.InstallPackagesJSON(dir, cfg.PackageManager, cfg.Offline); err != nil {
+		return err
+	}
+
+	// The functions framework is left external and installed by Cloud
+	// Functions, any other adapter is installed so it gets bundled
+	frameworkVersion := ""
+	var results []adapters.Result
+	for _, adapter := range adapters.For(platform.GCP, cfg) {
+		if adapter.Package == functionsFramework {
+			declared, ok := pkgJson.Dependencies[functionsFramework]
+			if !ok {
+				declared = adapter.Version
+			}
+			frameworkVersion = declared
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: declared, Added: !ok})
+			continue
+		}
+
+		if cfg.Offline && node.InstalledVersion(dir, adapter.Package) == "" {
+			return adapters.OfflineError(adapter)
+		}
+
+		installed, added, err := node.InstallPackage(dir, adapter.Package, adapter.Version, cfg.PackageManager)
+		if err != nil {
+			return err
+		}
+		results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed, Added: added})
+	}
+	adapters.PrintReport(results)
+
+	if cfg.Language == lang.JavaScript {
+		if err := node.Build(dir, pkgJson, cfg.PackageManager); err != nil {
+			return err
+		}
+	}
+
+	externals := append([]string{functionsFramework}, cfg.Bundle.Externals...)
+	nativeModules, err := node.FindNativeModules(dir)
+	if err != nil {
+		return fmt.Errorf("failed to detect native modules: %v", err)
+	}
+
+	err = node.Bundle(dir, node.BundleOptions{
+		Entrypoint: infra.GetHandlerFileName(cfg.Language),
+		Target:     node.RuntimeTarget(infra.GetPlatformRuntime(platform.GCP)),
+		Externals:  externals,
+	})
+	if err != nil {
+		return err
+	}
+
+	bundledPkgJson := &node.PackageJSON{
+		Scripts:      map[string]string{},
+		Dependencies: map[string]string{},
+		Other:        map[string]interface{}{"name": cfg.Name, "private": true},
+	}
+	node.SetMainInPackageJSON(bundledPkgJson, "upify_handler.js")
+	node.AddPackageToPackageJSON(bundledPkgJson, functionsFramework, frameworkVersion)
+	for _, external := range append(externals[1:], nativeModules...) {
+		if version, ok := pkgJson.Dependencies[external]; ok {
+			node.AddPackageToPackageJSON(bundledPkgJson, external, version)
+		}
+	}
+
+	return node.WritePackageJSON(pkgJsonPath, bundledPkgJson)
+}
+
 func adjustEntryPointFile(cfg *config.Config, tempDirPath string) error {
 	switch cfg.Language {
 	case lang.Python:
@@ -3,12 +3,8 @@ package gcp
 import (
 	_ "embed"
 	"fmt"
-	"strings"
 
-	"github.com/codeupify/upify/internal/config"
-	"github.com/codeupify/upify/internal/infra"
 	"github.com/codeupify/upify/internal/lang"
-	"github.com/codeupify/upify/internal/platform"
 )
 
 const pythonCode = `if os.getenv("UPIFY_DEPLOY_PLATFORM") == "gcp-cloudrun":
@@ -28,37 +24,29 @@ const nodeCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === 'gcp-cloudrun') {
     });
 }`
 
-//go:embed templates/main.tmpl
-var MainTemplate string
-
-//go:embed templates/main.module.tmpl
-var MainModuleTemplate string
-
-func AddPlatform(cfg *config.Config, region string, runtime string, projectId string) error {
-	fmt.Println("Adding GCP handlers...")
+const typescriptCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === 'gcp-cloudrun') {
+    const functions = require('@google-cloud/functions-framework');
+    functions.http('handler', (req: unknown, res: unknown) => {
+        app(req, res);
+    });
+}`
 
-	var handlerCode string
-	switch cfg.Language {
+// HandlerCode returns the handler section for the language
+func HandlerCode(language lang.Language) (string, error) {
+	switch language {
 	case lang.Python:
-		handlerCode = pythonCode
-	case lang.JavaScript, lang.TypeScript:
-		handlerCode = nodeCode
+		return pythonCode, nil
+	case lang.JavaScript:
+		return nodeCode, nil
+	case lang.TypeScript:
+		return typescriptCode, nil
 	default:
-		return fmt.Errorf("unsupported language: %s", cfg.Language)
+		return "", fmt.Errorf("unsupported language: %s", language)
 	}
+}
 
-	err := infra.AddPlatformHandler(cfg, "gcp-cloudrun", handlerCode)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("Setting up GCP Cloud Run infrastructure...")
-
-	mainContent := MainTemplate
-	mainContent = strings.Replace(mainContent, "{FUNCTION_NAME}", cfg.Name, -1)
-	mainContent = strings.Replace(mainContent, "{REGION}", region, -1)
-	mainContent = strings.Replace(mainContent, "{RUNTIME}", runtime, -1)
-	mainContent = strings.Replace(mainContent, "{PROJECT_ID}", projectId, -1)
+//go:embed templates/main.tmpl
+var MainTemplate string
 
-	return infra.AddPlatform(platform.GCP, mainContent, MainModuleTemplate)
-}
+//go:embed templates/main.module.tmpl
+var MainModuleTemplate string
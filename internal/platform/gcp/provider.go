@@ -0,0 +1,98 @@
+package gcp
+
+import (
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/image"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/pflag"
+)
+
+type provider struct {
+	region    string
+	projectId string
+	runtime   string
+}
+
+func init() {
+	platform.Register(&provider{})
+}
+
+func (p *provider) Name() platform.Platform {
+	return platform.GCP
+}
+
+func (p *provider) Title() string {
+	return "GCP"
+}
+
+func (p *provider) Short() string {
+	return "Add GCP configuration"
+}
+
+func (p *provider) Flags(flags *pflag.FlagSet) {
+	flags.StringVar(&p.region, "region", "", "GCP region")
+	flags.StringVar(&p.projectId, "project-id", "", "GCP project ID")
+	flags.StringVar(&p.runtime, "runtime", "", "Cloud Run runtime")
+}
+
+func (p *provider) Add(cfg *config.Config) error {
+	if err := platform.AskString(&p.region, "Enter GCP region:", "us-central1"); err != nil {
+		return err
+	}
+
+	if err := platform.AskString(&p.projectId, "Enter GCP project ID:", ""); err != nil {
+		return err
+	}
+
+	if err := platform.AskRuntime(&p.runtime, p, cfg.Language); err != nil {
+		return err
+	}
+
+	return infra.AddProvider(cfg, p, "gcp-cloudrun", map[string]string{
+		"{FUNCTION_NAME}": cfg.Name,
+		"{REGION}":        p.region,
+		"{RUNTIME}":       p.runtime,
+		"{PROJECT_ID}":    p.projectId,
+	})
+}
+
+func (p *provider) Runtimes(language lang.Language) []string {
+	switch language {
+	case lang.Python:
+		return []string{"python37", "python38", "python39", "python310", "python311", "python312"}
+	case lang.JavaScript, lang.TypeScript:
+		return []string{"nodejs10", "nodejs12", "nodejs14", "nodejs16", "nodejs18", "nodejs20", "nodejs22"}
+	default:
+		return []string{}
+	}
+}
+
+func (p *provider) HandlerCode(language lang.Language) (string, error) {
+	return HandlerCode(language)
+}
+
+func (p *provider) Templates() (string, string) {
+	return MainTemplate, MainModuleTemplate
+}
+
+func (p *provider) Stage(cfg *config.Config, dir string) error {
+	return Stage(cfg, dir)
+}
+
+func (p *provider) Deploy(cfg *config.Config, artifactPath string) error {
+	return Deploy(cfg, artifactPath)
+}
+
+func (p *provider) Destroy(cfg *config.Config) error {
+	return infra.Destroy(platform.GCP, map[string]string{"image_uri": ""}, "source_zip_path")
+}
+
+func (p *provider) Outputs() []string {
+	return []string{"cloud_run_service_url"}
+}
+
+func (p *provider) ImageConfig(cfg *config.Config) image.RuntimeConfig {
+	return ImageConfig(cfg)
+}
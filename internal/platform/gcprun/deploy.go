@@ -0,0 +1,378 @@
+package gcprun
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/image"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/lang/node"
+	"github.com/codeupify/upify/internal/lang/python"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/codeupify/upify/internal/platform/adapters"
+)
+
+// Deploy applies the platform's terraform configuration. Zip packages are
+// built into an image by Cloud Build; when artifactPath is empty the project
+// is staged and zipped first, otherwise the prebuilt artifact is deployed as
+// is. Image packages are built locally and pushed
+func Deploy(cfg *config.Config, artifactPath string) error {
+	if cfg.ImagePackaging() && artifactPath != "" {
+		return fmt.Errorf("--artifact is only supported when package_type is zip")
+	}
+
+	if artifactPath == "" {
+		if err := infra.PreDeployValidate(cfg, platform.GCPRun); err != nil {
+			return err
+		}
+	} else if err := infra.ValidateTerraformDir(platform.GCPRun); err != nil {
+		return err
+	}
+
+	if err := infra.WriteEnvironmentVariables(platform.GCPRun); err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "gcp_run_deployment_")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	imageRef := ""
+	if cfg.ImagePackaging() {
+		stagingDir := filepath.Join(tempDir, "source")
+		if err := Stage(cfg, stagingDir); err != nil {
+			return err
+		}
+
+		artifactPath = filepath.Join(tempDir, "image.tar")
+		img, err := infra.CreateImage(cfg, platform.GCPRun, stagingDir, ImageConfig(cfg), filepath.Join(tempDir, "image"), artifactPath)
+		if err != nil {
+			return err
+		}
+
+		imageRef, err = infra.PushImage(cfg, img)
+		if err != nil {
+			return err
+		}
+	} else if artifactPath == "" {
+		stagingDir := filepath.Join(tempDir, "source")
+		if err := Stage(cfg, stagingDir); err != nil {
+			return err
+		}
+
+		artifactPath = filepath.Join(tempDir, "source.zip")
+		if err := infra.CreateArtifact(cfg, platform.GCPRun, stagingDir, artifactPath); err != nil {
+			return err
+		}
+	}
+
+	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.GCPRun))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	vars := map[string]string{
+		"source_zip_path": "",
+		"image_uri":       imageRef,
+	}
+	if imageRef == "" {
+		vars["source_zip_path"] = artifactPath
+	}
+
+	ctx := context.Background()
+	if err := terraformManager.Apply(ctx, vars); err != nil {
+		return err
+	}
+
+	return infra.RecordDeploy(cfg, platform.GCPRun, artifactPath, imageRef)
+}
+
+// ImageConfig serves the app on the PORT Cloud Run sets: Python apps with
+// gunicorn, Node.js apps by running upify_handler, which starts their own
+// server. The base image provides the language runtime
+func ImageConfig(cfg *config.Config) image.RuntimeConfig {
+	rc := image.RuntimeConfig{
+		AppDir:     "/app",
+		WorkingDir: "/app",
+		Entrypoint: infra.ServerCommand(cfg),
+		Cmd:        []string{},
+	}
+
+	if cfg.Language == lang.Python {
+		rc.Env = map[string]string{"PYTHONPATH": "/app"}
+	}
+
+	return rc
+}
+
+// Stage copies the project into dir and writes the Procfile and runtime
+// version the Cloud Build buildpacks read, leaving dir ready to be zipped.
+// Dependencies are installed by the build, not locally, except for image
+// packages which have to run as built
+func Stage(cfg *config.Config, dir string) error {
+	err := infra.CopySource(cfg, dir)
+	if err != nil {
+		return err
+	}
+
+	runtime := infra.GetPlatformRuntime(platform.GCPRun)
+
+	switch cfg.Language {
+	case lang.Python:
+		// The buildpack installs Python dependencies from requirements.txt
+		if _, err := python.WriteRequirements(dir, cfg.GetSourceDir(), cfg.PackageManager); err != nil {
+			return fmt.Errorf("failed to write requirements.txt: %v", err)
+		}
+
+		var results []adapters.Result
+		for _, adapter := range adapters.For(platform.GCPRun, cfg) {
+			declared, added, err := python.AddRequirement(dir, adapter.Package, adapter.Version)
+			if err != nil {
+				return fmt.Errorf("failed to update requirements.txt: %v", err)
+			}
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: declared, Added: added})
+		}
+		adapters.PrintReport(results)
+
+		if version := python.RuntimeVersion(runtime); version != "" {
+			if err := writeIfMissing(filepath.Join(dir, ".python-version"), version+"\n"); err != nil {
+				return fmt.Errorf("failed to write .python-version: %v", err)
+			}
+		}
+
+	case lang.JavaScript, lang.TypeScript:
+		if cfg.BundleEnabled() {
+			err = bundleNodeProject(cfg, dir, runtime)
+			if err != nil {
+				return fmt.Errorf("failed to bundle project: %v", err)
+			}
+		} else {
+			err = updatePackageJson(cfg, dir, runtime)
+			if err != nil {
+				return fmt.Errorf("failed to update package.json: %v", err)
+			}
+		}
+
+	default:
+		return fmt.Errorf("unsupported language: %s", cfg.Language)
+	}
+
+	if err := writeIfMissing(filepath.Join(dir, "Procfile"), "web: "+strings.Join(infra.ServerCommand(cfg), " ")+"\n"); err != nil {
+		return fmt.Errorf("failed to write Procfile: %v", err)
+	}
+
+	if cfg.ImagePackaging() {
+		err = installForImage(cfg, dir, runtime)
+		if err != nil {
+			return fmt.Errorf("failed to install requirements: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// installForImage does what the buildpacks would: install the dependencies
+// declared in the staged requirements.txt or package.json and run the build
+func installForImage(cfg *config.Config, dir string, runtime string) error {
+	switch cfg.Language {
+	case lang.Python:
+		py, err := python.FindInterpreter(cfg.GetSourceDir(), cfg.PythonInterpreter())
+		if err != nil {
+			return err
+		}
+
+		if err := py.CheckRuntime(runtime); err != nil {
+			return err
+		}
+
+		if err := py.CheckPip(); err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "requirements.txt")); os.IsNotExist(err) {
+			return nil
+		}
+
+		fmt.Printf("Installing requirements with %s (Python %s)...\n", py.Path, py.Version)
+		if err := py.Pip(dir, "install", "-r", "requirements.txt", "-t", dir); err != nil {
+			return fmt.Errorf("failed to install Python requirements: %v", err)
+		}
+
+		return nil
+
+	case lang.JavaScript, lang.TypeScript:
+		// The bundle already holds everything, externals included
+		if cfg.BundleEnabled() {
+			return nil
+		}
+
+		pkgJson, err := node.ParsePackageJSON(filepath.Join(dir, "package.json"))
+		if err != nil {
+			return fmt.Errorf("failed to parse package.json: %v", err)
+		}
+
+		if err := node.InstallPackagesJSON(dir, cfg.PackageManager, cfg.Offline); err != nil {
+			return err
+		}
+
+		if err := node.Build(dir, pkgJson, cfg.PackageManager); err != nil {
+			return err
+		}
+
+		if cfg.Language == lang.TypeScript {
+			if err := node.CompileTypeScript(dir); err != nil {
+				return err
+			}
+		}
+
+		return node.PruneDevDependencies(dir, cfg.PackageManager, cfg.Offline)
+
+	default:
+		return fmt.Errorf("unsupported language: %s", cfg.Language)
+	}
+}
+
+// updatePackageJson declares the adapters, the build the buildpack runs and
+// the Node.js version it installs
+func updatePackageJson(cfg *config.Config, dir string, runtime string) error {
+	pkgJson, err := node.ParsePackageJSON(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return fmt.Errorf("failed to parse package.json: %v", err)
+	}
+
+	var results []adapters.Result
+	for _, adapter := range adapters.For(platform.GCPRun, cfg) {
+		if declared, ok := pkgJson.Dependencies[adapter.Package]; ok {
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: declared})
+			continue
+		}
+
+		// Updating the lockfile would need the registry
+		if cfg.Offline && node.HasLockfile(dir, cfg.PackageManager) {
+			return adapters.OfflineError(adapter)
+		}
+
+		node.AddPackageToPackageJSON(pkgJson, adapter.Package, adapter.Version)
+		if err := node.UpdateLockfile(dir, adapter.Package, adapter.Version, cfg.PackageManager); err != nil {
+			return err
+		}
+		results = append(results, adapters.Result{Adapter: adapter, Added: true})
+	}
+	adapters.PrintReport(results)
+
+	// The Node.js buildpack runs gcp-build after installing dependencies
+	if cfg.Language == lang.TypeScript {
+		compileCommand, err := node.PrepareTypeScript(dir)
+		if err != nil {
+			return err
+		}
+
+		node.AddScriptToPackageJSON(pkgJson, "gcp-build", compileCommand)
+	} else if pkgJson.Scripts != nil && pkgJson.Scripts["build"] != "" {
+		node.AddScriptToPackageJSON(pkgJson, "gcp-build", node.BuildpackScriptCommand(cfg.PackageManager, "build"))
+	}
+
+	setNodeEngine(pkgJson, runtime)
+
+	return node.WritePackageJSON(filepath.Join(dir, "package.json"), pkgJson)
+}
+
+// bundleNodeProject installs and bundles the project locally, then writes a
+// package.json that only lists the packages left external, since those are
+// all the buildpack needs to install
+func bundleNodeProject(cfg *config.Config, dir string, runtime string) error {
+	pkgJsonPath := filepath.Join(dir, "package.json")
+	pkgJson, err := node.ParsePackageJSON(pkgJsonPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse package.json: %v", err)
+	}
+
+	if err := node.InstallPackagesJSON(dir, cfg.PackageManager, cfg.Offline); err != nil {
+		return err
+	}
+
+	var results []adapters.Result
+	for _, adapter := range adapters.For(platform.GCPRun, cfg) {
+		if cfg.Offline && node.InstalledVersion(dir, adapter.Package) == "" {
+			return adapters.OfflineError(adapter)
+		}
+
+		installed, added, err := node.InstallPackage(dir, adapter.Package, adapter.Version, cfg.PackageManager)
+		if err != nil {
+			return err
+		}
+		results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: installed, Added: added})
+	}
+	adapters.PrintReport(results)
+
+	if cfg.Language == lang.JavaScript {
+		if err := node.Build(dir, pkgJson, cfg.PackageManager); err != nil {
+			return err
+		}
+	}
+
+	nativeModules, err := node.FindNativeModules(dir)
+	if err != nil {
+		return fmt.Errorf("failed to detect native modules: %v", err)
+	}
+
+	err = node.Bundle(dir, node.BundleOptions{
+		Entrypoint: infra.GetHandlerFileName(cfg.Language),
+		Target:     node.RuntimeTarget(runtime),
+		Externals:  cfg.Bundle.Externals,
+	})
+	if err != nil {
+		return err
+	}
+
+	bundledPkgJson := &node.PackageJSON{
+		Scripts:      map[string]string{},
+		Dependencies: map[string]string{},
+		Other:        map[string]interface{}{"name": cfg.Name, "private": true},
+	}
+	node.SetMainInPackageJSON(bundledPkgJson, "upify_handler.js")
+	for _, external := range append(cfg.Bundle.Externals, nativeModules...) {
+		if version, ok := pkgJson.Dependencies[external]; ok {
+			node.AddPackageToPackageJSON(bundledPkgJson, external, version)
+		}
+	}
+	setNodeEngine(bundledPkgJson, runtime)
+
+	return node.WritePackageJSON(pkgJsonPath, bundledPkgJson)
+}
+
+// setNodeEngine pins engines.node to the runtime's major version unless the
+// project already sets it
+func setNodeEngine(pkgJson *node.PackageJSON, runtime string) {
+	target := node.RuntimeTarget(runtime)
+	if target == "" {
+		return
+	}
+
+	engines, _ := pkgJson.Other["engines"].(map[string]interface{})
+	if engines == nil {
+		engines = map[string]interface{}{}
+	}
+	if _, ok := engines["node"]; ok {
+		return
+	}
+
+	engines["node"] = strings.TrimPrefix(target, "node") + ".x"
+	pkgJson.Other["engines"] = engines
+}
+
+// writeIfMissing writes a file the buildpacks read, leaving the project's own
+// version in place
+func writeIfMissing(path string, content string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
@@ -0,0 +1,51 @@
+package gcprun
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// HandlerCode returns the handler section for the language
+func HandlerCode(language lang.Language) (string, error) {
+	return infra.ServerHandlerCode(platform.GCPRun, language)
+}
+
+//go:embed templates/main.tmpl
+var MainTemplate string
+
+//go:embed templates/main.module.tmpl
+var MainModuleTemplate string
+
+// Ingresses are the accepted values of the ingress setting
+var Ingresses = []string{"all", "internal", "internal-and-cloud-load-balancing"}
+
+// Service holds the Cloud Run settings written to main.tf, where they can be
+// changed later
+type Service struct {
+	Concurrency  int
+	CPU          string
+	Memory       string
+	MinInstances int
+	MaxInstances int
+	Ingress      string
+}
+
+func (s Service) validate() error {
+	if s.Concurrency < 1 || s.Concurrency > 1000 {
+		return fmt.Errorf("concurrency must be between 1 and 1000, got %d", s.Concurrency)
+	}
+	if s.MinInstances < 0 || s.MaxInstances < 1 || s.MinInstances > s.MaxInstances {
+		return fmt.Errorf("invalid instance range %d-%d", s.MinInstances, s.MaxInstances)
+	}
+	for _, ingress := range Ingresses {
+		if s.Ingress == ingress {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported ingress: %s (use %s)", s.Ingress, strings.Join(Ingresses, ", "))
+}
@@ -0,0 +1,117 @@
+package gcprun
+
+import (
+	"strconv"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/image"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/pflag"
+)
+
+type provider struct {
+	region    string
+	projectId string
+	runtime   string
+	service   Service
+}
+
+func init() {
+	platform.Register(&provider{})
+}
+
+func (p *provider) Name() platform.Platform {
+	return platform.GCPRun
+}
+
+func (p *provider) Title() string {
+	return "GCP Cloud Run"
+}
+
+func (p *provider) Short() string {
+	return "Add GCP Cloud Run service configuration"
+}
+
+func (p *provider) Flags(flags *pflag.FlagSet) {
+	flags.StringVar(&p.region, "region", "", "GCP region")
+	flags.StringVar(&p.projectId, "project-id", "", "GCP project ID")
+	flags.StringVar(&p.runtime, "runtime", "", "Language runtime")
+	flags.IntVar(&p.service.Concurrency, "concurrency", 80, "Maximum concurrent requests per instance")
+	flags.StringVar(&p.service.CPU, "cpu", "1", "CPU limit per instance")
+	flags.StringVar(&p.service.Memory, "memory", "512Mi", "Memory limit per instance")
+	flags.IntVar(&p.service.MinInstances, "min-instances", 0, "Minimum number of instances")
+	flags.IntVar(&p.service.MaxInstances, "max-instances", 10, "Maximum number of instances")
+	flags.StringVar(&p.service.Ingress, "ingress", "all", "Allowed traffic: all, internal or internal-and-cloud-load-balancing")
+}
+
+func (p *provider) Add(cfg *config.Config) error {
+	if err := platform.AskString(&p.region, "Enter GCP region:", "us-central1"); err != nil {
+		return err
+	}
+
+	if err := platform.AskString(&p.projectId, "Enter GCP project ID:", ""); err != nil {
+		return err
+	}
+
+	if err := platform.AskRuntime(&p.runtime, p, cfg.Language); err != nil {
+		return err
+	}
+
+	if err := p.service.validate(); err != nil {
+		return err
+	}
+
+	return infra.AddProvider(cfg, p, "gcp-run", map[string]string{
+		"{SERVICE_NAME}":  cfg.Name,
+		"{REGION}":        p.region,
+		"{RUNTIME}":       p.runtime,
+		"{PROJECT_ID}":    p.projectId,
+		"{CONCURRENCY}":   strconv.Itoa(p.service.Concurrency),
+		"{CPU}":           p.service.CPU,
+		"{MEMORY}":        p.service.Memory,
+		"{MIN_INSTANCES}": strconv.Itoa(p.service.MinInstances),
+		"{MAX_INSTANCES}": strconv.Itoa(p.service.MaxInstances),
+		"{INGRESS}":       p.service.Ingress,
+	})
+}
+
+func (p *provider) Runtimes(language lang.Language) []string {
+	switch language {
+	case lang.Python:
+		return []string{"python39", "python310", "python311", "python312"}
+	case lang.JavaScript, lang.TypeScript:
+		return []string{"nodejs18", "nodejs20", "nodejs22"}
+	default:
+		return []string{}
+	}
+}
+
+func (p *provider) HandlerCode(language lang.Language) (string, error) {
+	return HandlerCode(language)
+}
+
+func (p *provider) Templates() (string, string) {
+	return MainTemplate, MainModuleTemplate
+}
+
+func (p *provider) Stage(cfg *config.Config, dir string) error {
+	return Stage(cfg, dir)
+}
+
+func (p *provider) Deploy(cfg *config.Config, artifactPath string) error {
+	return Deploy(cfg, artifactPath)
+}
+
+func (p *provider) Destroy(cfg *config.Config) error {
+	return infra.Destroy(platform.GCPRun, map[string]string{"image_uri": ""}, "source_zip_path")
+}
+
+func (p *provider) Outputs() []string {
+	return []string{"service_url"}
+}
+
+func (p *provider) ImageConfig(cfg *config.Config) image.RuntimeConfig {
+	return ImageConfig(cfg)
+}
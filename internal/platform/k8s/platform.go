@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// HandlerCode returns the handler section for the language
+func HandlerCode(language lang.Language) (string, error) {
+	return infra.ServerHandlerCode(platform.K8s, language)
+}
+
+//go:embed templates/main.tmpl
+var MainTemplate string
+
+//go:embed templates/main.module.tmpl
+var MainModuleTemplate string
+
+// dnsLabel is the format of Kubernetes resource and namespace names
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// Cluster holds the cluster to deploy to and the workload settings written
+// to main.tf, where they can be changed later. An empty Context uses the
+// kubeconfig's current context
+type Cluster struct {
+	Kubeconfig      string
+	Context         string
+	Namespace       string
+	Replicas        int
+	CPU             string
+	Memory          string
+	HealthCheckPath string
+	Host            string
+	IngressClass    string
+	Knative         bool
+}
+
+func (c Cluster) validate(name string) error {
+	if !dnsLabel.MatchString(name) {
+		return fmt.Errorf("project name %q isn't a valid Kubernetes name (lowercase letters, digits and -)", name)
+	}
+	if !dnsLabel.MatchString(c.Namespace) {
+		return fmt.Errorf("invalid namespace: %q", c.Namespace)
+	}
+	if c.Replicas < 1 {
+		return fmt.Errorf("replicas must be at least 1, got %d", c.Replicas)
+	}
+	if !strings.HasPrefix(c.HealthCheckPath, "/") {
+		return fmt.Errorf("health check path must start with /, got %q", c.HealthCheckPath)
+	}
+	if c.Knative && c.Host != "" {
+		return fmt.Errorf("--host is not used with --knative, which routes through the Knative domain")
+	}
+	return nil
+}
+
+// Render fills in the environment's main.tf. It only depends on its
+// arguments, so the output can be compared against golden files
+func Render(name string, runtime string, cluster Cluster) string {
+	return infra.RenderTemplate(MainTemplate, replacements(name, runtime, cluster))
+}
+
+func replacements(name string, runtime string, cluster Cluster) map[string]string {
+	return map[string]string{
+		"{NAME}":              name,
+		"{RUNTIME}":           runtime,
+		"{KUBECONFIG}":        cluster.Kubeconfig,
+		"{CONTEXT}":           cluster.Context,
+		"{NAMESPACE}":         cluster.Namespace,
+		"{REPLICAS}":          strconv.Itoa(cluster.Replicas),
+		"{CPU}":               cluster.CPU,
+		"{MEMORY}":            cluster.Memory,
+		"{HEALTH_CHECK_PATH}": cluster.HealthCheckPath,
+		"{HOST}":              cluster.Host,
+		"{INGRESS_CLASS}":     cluster.IngressClass,
+		"{KNATIVE}":           strconv.FormatBool(cluster.Knative),
+	}
+}
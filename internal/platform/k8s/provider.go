@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/image"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/pflag"
+)
+
+type provider struct {
+	runtime string
+	cluster Cluster
+}
+
+func init() {
+	platform.Register(&provider{})
+}
+
+func (p *provider) Name() platform.Platform {
+	return platform.K8s
+}
+
+func (p *provider) Title() string {
+	return "Kubernetes"
+}
+
+func (p *provider) Short() string {
+	return "Add Kubernetes configuration"
+}
+
+func (p *provider) Flags(flags *pflag.FlagSet) {
+	flags.StringVar(&p.runtime, "runtime", "", "Language runtime")
+	flags.StringVar(&p.cluster.Kubeconfig, "kubeconfig", "~/.kube/config", "Path to the kubeconfig")
+	flags.StringVar(&p.cluster.Context, "context", "", "Kubeconfig context (default the current context)")
+	flags.StringVar(&p.cluster.Namespace, "namespace", "default", "Namespace to deploy to")
+	flags.IntVar(&p.cluster.Replicas, "replicas", 2, "Number of pods (maximum scale with --knative)")
+	flags.StringVar(&p.cluster.CPU, "cpu", "250m", "CPU per pod")
+	flags.StringVar(&p.cluster.Memory, "memory", "256Mi", "Memory per pod")
+	flags.StringVar(&p.cluster.HealthCheckPath, "health-check-path", "/", "Path of the readiness and liveness probes")
+	flags.StringVar(&p.cluster.Host, "host", "", "Host name for an Ingress (no Ingress when empty)")
+	flags.StringVar(&p.cluster.IngressClass, "ingress-class", "", "IngressClass of the Ingress")
+	flags.BoolVar(&p.cluster.Knative, "knative", false, "Deploy a Knative Service instead of a Deployment")
+}
+
+func (p *provider) Add(cfg *config.Config) error {
+	if err := platform.AskRuntime(&p.runtime, p, cfg.Language); err != nil {
+		return err
+	}
+
+	if err := p.cluster.validate(cfg.Name); err != nil {
+		return err
+	}
+
+	return infra.AddProvider(cfg, p, "k8s", replacements(cfg.Name, p.runtime, p.cluster))
+}
+
+func (p *provider) Runtimes(language lang.Language) []string {
+	return platform.ContainerRuntimes(language)
+}
+
+func (p *provider) HandlerCode(language lang.Language) (string, error) {
+	return HandlerCode(language)
+}
+
+func (p *provider) Templates() (string, string) {
+	return MainTemplate, MainModuleTemplate
+}
+
+func (p *provider) Stage(cfg *config.Config, dir string) error {
+	return Stage(cfg, dir)
+}
+
+func (p *provider) Deploy(cfg *config.Config, artifactPath string) error {
+	return Deploy(cfg, artifactPath)
+}
+
+func (p *provider) Destroy(cfg *config.Config) error {
+	return infra.Destroy(platform.K8s, map[string]string{"image_uri": ""})
+}
+
+func (p *provider) Outputs() []string {
+	return []string{"service_url"}
+}
+
+func (p *provider) ImageConfig(cfg *config.Config) image.RuntimeConfig {
+	return ImageConfig(cfg)
+}
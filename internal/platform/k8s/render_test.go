@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		golden  string
+		runtime string
+		cluster Cluster
+	}{
+		{
+			golden:  "deployment_ingress.golden",
+			runtime: "python3.12",
+			cluster: Cluster{
+				Kubeconfig:      "~/.kube/config",
+				Context:         "prod",
+				Namespace:       "apps",
+				Replicas:        3,
+				CPU:             "500m",
+				Memory:          "512Mi",
+				HealthCheckPath: "/healthz",
+				Host:            "api.example.com",
+				IngressClass:    "nginx",
+			},
+		},
+		{
+			golden:  "knative.golden",
+			runtime: "nodejs20",
+			cluster: Cluster{
+				Kubeconfig:      "~/.kube/config",
+				Namespace:       "default",
+				Replicas:        5,
+				CPU:             "250m",
+				Memory:          "256Mi",
+				HealthCheckPath: "/",
+				Knative:         true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			if err := tt.cluster.validate("my-app"); err != nil {
+				t.Fatal(err)
+			}
+
+			got := Render("my-app", tt.runtime, tt.cluster)
+			for _, placeholder := range []string{"{NAME}", "{RUNTIME}", "{KUBECONFIG}", "{CONTEXT}", "{NAMESPACE}", "{REPLICAS}", "{CPU}", "{MEMORY}", "{HEALTH_CHECK_PATH}", "{HOST}", "{INGRESS_CLASS}", "{KNATIVE}"} {
+				if strings.Contains(got, placeholder) {
+					t.Errorf("%s left in the output", placeholder)
+				}
+			}
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Render output differs from %s, rerun with -update to accept it:\n%s", path, got)
+			}
+		})
+	}
+}
+
+func TestClusterValidate(t *testing.T) {
+	valid := Cluster{Namespace: "default", Replicas: 1, HealthCheckPath: "/"}
+
+	tests := []struct {
+		name    string
+		project string
+		modify  func(c *Cluster)
+		wantErr string
+	}{
+		{name: "valid", project: "my-app", modify: func(c *Cluster) {}},
+		{name: "project name", project: "My_App", modify: func(c *Cluster) {}, wantErr: "isn't a valid Kubernetes name"},
+		{name: "namespace", project: "my-app", modify: func(c *Cluster) { c.Namespace = "Apps" }, wantErr: "invalid namespace"},
+		{name: "replicas", project: "my-app", modify: func(c *Cluster) { c.Replicas = 0 }, wantErr: "replicas must be at least 1"},
+		{name: "health check path", project: "my-app", modify: func(c *Cluster) { c.HealthCheckPath = "healthz" }, wantErr: "must start with /"},
+		{name: "knative with host", project: "my-app", modify: func(c *Cluster) { c.Knative = true; c.Host = "api.example.com" }, wantErr: "--host is not used with --knative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := valid
+			tt.modify(&cluster)
+
+			err := cluster.validate(tt.project)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
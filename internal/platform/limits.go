@@ -0,0 +1,24 @@
+package platform
+
+const megabyte = 1024 * 1024
+
+type SizeLimits struct {
+	Zipped   int64
+	Unzipped int64
+}
+
+// Limits holds the maximum artifact sizes accepted by each platform. AWS
+// Lambda allows 50 MB for a direct zip upload and 250 MB unzipped; Cloud
+// Functions allows 100 MB of compressed source and 500 MB uncompressed;
+// Azure Functions on the Consumption plan has 1 GB of storage for the app;
+// Cloudflare Workers allows 10 MB compressed on the paid plan (3 MB free).
+// AWS ECS, Cloud Run and Kubernetes run container images and self-hosted
+// servers are only bound by their disks, so they have no limits and
+// CheckArtifactSize skips them
+var Limits = map[Platform]SizeLimits{
+	AWS:   {Zipped: 50 * megabyte, Unzipped: 250 * megabyte},
+	GCP:   {Zipped: 100 * megabyte, Unzipped: 500 * megabyte},
+	Azure: {Zipped: 1024 * megabyte, Unzipped: 1024 * megabyte},
+
+	Cloudflare: {Zipped: 10 * megabyte},
+}
@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// Prefix is the file name prefix of plugin executables, the rest of the
+// name is the platform's name
+const Prefix = "upify-platform-"
+
+var pluginName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Dirs returns the directories searched for plugins in order,
+// ~/.upify/plugins first and then the PATH
+func Dirs() []string {
+	var dirs []string
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(homeDir, ".upify", "plugins"))
+	}
+	return append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+}
+
+// Discover registers a provider for every plugin found in Dirs, returning
+// their names. The first plugin found for a name wins, and plugins can't
+// replace the built-in platforms, so it has to run after those registered
+func Discover() []string {
+	var names []string
+	for _, dir := range Dirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := parseName(entry.Name())
+			if !ok {
+				continue
+			}
+
+			if _, err := platform.Get(platform.Platform(name)); err == nil {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+
+			platform.Register(&Provider{name: name, path: path})
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func parseName(fileName string) (string, bool) {
+	if !strings.HasPrefix(fileName, Prefix) {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(fileName, Prefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+	}
+
+	return name, pluginName.MatchString(name)
+}
+
+func isExecutable(path string) bool {
+	// Follows symlinks, which is how plugins are usually installed
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	if runtime.GOOS == "windows" {
+		return strings.HasSuffix(strings.ToLower(path), ".exe")
+	}
+	return info.Mode()&0111 != 0
+}
@@ -0,0 +1,132 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+)
+
+// ProtocolVersion is sent with every request. Plugins that answer with a
+// different version are refused
+const ProtocolVersion = 1
+
+// Commands of the protocol, passed to the plugin as its only argument
+const (
+	CommandAdd     = "add"
+	CommandPackage = "package"
+	CommandDeploy  = "deploy"
+	CommandDestroy = "destroy"
+	CommandStatus  = "status"
+)
+
+// Request is written to the plugin's stdin as a single JSON document
+type Request struct {
+	Protocol int     `json:"protocol"`
+	Command  string  `json:"command"`
+	Platform string  `json:"platform"`
+	Project  Project `json:"project"`
+	// Settings is what the plugin returned from add, absent for add itself
+	Settings json.RawMessage `json:"settings,omitempty"`
+	// Options are the --set values and prompt answers (add)
+	Options map[string]string `json:"options,omitempty"`
+	// Dir is the staging directory to prepare for zipping (package)
+	Dir string `json:"dir,omitempty"`
+	// Artifact is the zip to deploy (deploy)
+	Artifact string `json:"artifact,omitempty"`
+	// Env holds the variables from .upify/.env.prod or .upify/.env (deploy)
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Project is the part of config.yaml plugins get to see
+type Project struct {
+	Name           string `json:"name"`
+	Language       string `json:"language"`
+	Framework      string `json:"framework,omitempty"`
+	PackageManager string `json:"package_manager,omitempty"`
+	Entrypoint     string `json:"entrypoint,omitempty"`
+	AppVar         string `json:"app_var,omitempty"`
+	SourceDir      string `json:"source_dir"`
+}
+
+// Response is read from the plugin's stdout. An empty stdout is an empty
+// response
+type Response struct {
+	Protocol int    `json:"protocol,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Prompts asks for missing options, add is sent again with the answers
+	Prompts []Prompt `json:"prompts,omitempty"`
+	// Settings is stored in the platform's directory and sent back with
+	// every later request (add)
+	Settings json.RawMessage `json:"settings,omitempty"`
+	// HandlerCode is appended to upify_handler as the platform's section,
+	// {APP_VAR} is replaced with the app variable (add)
+	HandlerCode string `json:"handler_code,omitempty"`
+	// Status is a one line summary of the deployment (status)
+	Status string `json:"status,omitempty"`
+	// Outputs are printed as name: value, e.g. the URL (deploy, status)
+	Outputs map[string]interface{} `json:"outputs,omitempty"`
+}
+
+// Prompt is an option the user is asked for
+type Prompt struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Default string `json:"default,omitempty"`
+}
+
+func newProject(cfg *config.Config) Project {
+	return Project{
+		Name:           cfg.Name,
+		Language:       string(cfg.Language),
+		Framework:      string(cfg.Framework),
+		PackageManager: string(cfg.PackageManager),
+		Entrypoint:     cfg.Entrypoint,
+		AppVar:         cfg.AppVar,
+		SourceDir:      cfg.GetSourceDir(),
+	}
+}
+
+// call runs the plugin with the request's command, from the project root.
+// Its stderr goes straight to the user, so plugins can report progress there
+func call(path string, request Request) (*Response, error) {
+	request.Protocol = ProtocolVersion
+
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(path, request.Command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+
+	response := &Response{}
+	if output := bytes.TrimSpace(stdout.Bytes()); len(output) > 0 {
+		if err := json.Unmarshal(output, response); err != nil {
+			if runErr != nil {
+				return nil, fmt.Errorf("plugin %s failed: %v", request.Platform, runErr)
+			}
+			return nil, fmt.Errorf("invalid response from plugin %s: %v", request.Platform, err)
+		}
+	}
+
+	if response.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", request.Platform, strings.TrimSpace(response.Error))
+	}
+	if runErr != nil {
+		return nil, fmt.Errorf("plugin %s failed: %v", request.Platform, runErr)
+	}
+	if response.Protocol != 0 && response.Protocol != ProtocolVersion {
+		return nil, fmt.Errorf("plugin %s speaks protocol version %d, this upify speaks %d", request.Platform, response.Protocol, ProtocolVersion)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,261 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/pflag"
+)
+
+// settingsFile holds what the plugin returned from add, in the platform's
+// directory under .upify/environments/prod
+const settingsFile = "plugin.json"
+
+// maxPromptRounds stops plugins that keep asking for options
+const maxPromptRounds = 5
+
+// Provider is a platform implemented by an external executable, see
+// docs/plugins.md for the protocol
+type Provider struct {
+	name    string
+	path    string
+	options map[string]string
+}
+
+func (p *Provider) Name() platform.Platform {
+	return platform.Platform(p.name)
+}
+
+func (p *Provider) Title() string {
+	return p.name
+}
+
+func (p *Provider) Short() string {
+	return fmt.Sprintf("Add %s configuration (plugin %s)", p.name, p.path)
+}
+
+func (p *Provider) Flags(flags *pflag.FlagSet) {
+	flags.StringToStringVar(&p.options, "set", nil, "Plugin option as key=value, can be repeated")
+}
+
+// Add asks the plugin for its settings, prompting for any option it asks
+// for, and stores them along with its handler section
+func (p *Provider) Add(cfg *config.Config) error {
+	platformDir := infra.GetPlatformTerraformDir(p.Name())
+	if _, err := os.Stat(platformDir); err == nil {
+		return fmt.Errorf("%s already exists", platformDir)
+	}
+
+	options := map[string]string{}
+	for key, value := range p.options {
+		options[key] = value
+	}
+
+	var response *Response
+	for round := 0; ; round++ {
+		var err error
+		response, err = p.call(cfg, Request{Command: CommandAdd, Options: options})
+		if err != nil {
+			return err
+		}
+		if len(response.Prompts) == 0 {
+			break
+		}
+		if round == maxPromptRounds {
+			return fmt.Errorf("plugin %s kept asking for options", p.name)
+		}
+
+		for _, prompt := range response.Prompts {
+			value := options[prompt.Name]
+			if err := platform.AskString(&value, prompt.Message, prompt.Default); err != nil {
+				return err
+			}
+			options[prompt.Name] = value
+		}
+	}
+
+	if response.HandlerCode != "" {
+		fmt.Printf("Adding %s handlers...\n", p.name)
+		if err := infra.AddPlatformHandler(cfg, p.name, response.HandlerCode); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(platformDir, 0755); err != nil {
+		return fmt.Errorf("failed to create environments directory: %w", err)
+	}
+
+	settings := response.Settings
+	if len(settings) == 0 {
+		settings = json.RawMessage("{}")
+	}
+
+	settingsPath := filepath.Join(platformDir, settingsFile)
+	fmt.Printf("Creating %s...\n", settingsPath)
+	if err := os.WriteFile(settingsPath, settings, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", settingsFile, err)
+	}
+
+	return nil
+}
+
+func (p *Provider) Runtimes(language lang.Language) []string {
+	// The plugin picks the runtime, through its own options
+	return []string{}
+}
+
+func (p *Provider) HandlerCode(language lang.Language) (string, error) {
+	// The handler section comes with the plugin's add response
+	return "", nil
+}
+
+func (p *Provider) Templates() (string, string) {
+	// Plugins don't deploy with upify's terraform
+	return "", ""
+}
+
+// Stage copies the project into dir and lets the plugin prepare it, e.g. by
+// installing the dependencies or adding its own files
+func (p *Provider) Stage(cfg *config.Config, dir string) error {
+	if err := infra.CopySource(cfg, dir); err != nil {
+		return err
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve staging directory: %v", err)
+	}
+
+	_, err = p.call(cfg, Request{Command: CommandPackage, Dir: absDir})
+	return err
+}
+
+// Deploy hands the zip to the plugin along with the environment variables.
+// When artifactPath is empty the project is staged and zipped first,
+// otherwise the prebuilt artifact is deployed as is
+func (p *Provider) Deploy(cfg *config.Config, artifactPath string) error {
+	if cfg.ImagePackaging() {
+		return fmt.Errorf("package_type image is not supported on plugin platforms")
+	}
+
+	if err := p.preDeployValidate(cfg); err != nil {
+		return err
+	}
+
+	env, err := infra.LoadEnvironmentVariables()
+	if err != nil {
+		return err
+	}
+
+	if artifactPath == "" {
+		tempDir, err := os.MkdirTemp("", "plugin_deployment_")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		stagingDir := filepath.Join(tempDir, "source")
+		if err := p.Stage(cfg, stagingDir); err != nil {
+			return err
+		}
+
+		artifactPath = filepath.Join(tempDir, "source.zip")
+		if err := infra.CreateArtifact(cfg, p.Name(), stagingDir, artifactPath); err != nil {
+			return err
+		}
+	}
+
+	response, err := p.call(cfg, Request{Command: CommandDeploy, Artifact: artifactPath, Env: env})
+	if err != nil {
+		return err
+	}
+	printOutputs(response.Outputs)
+
+	return infra.RecordDeploy(cfg, p.Name(), artifactPath, "")
+}
+
+// preDeployValidate is infra.PreDeployValidate for plugins, which keep the
+// settings from add instead of a main.tf in the platform's directory
+func (p *Provider) preDeployValidate(cfg *config.Config) error {
+	if err := infra.ValidatePlatformDir(p.Name()); err != nil {
+		return err
+	}
+
+	settingsPath := filepath.Join(infra.GetPlatformTerraformDir(p.Name()), settingsFile)
+	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
+		return fmt.Errorf("couldn't find %s, did you run `upify platform add %s`?", settingsPath, p.name)
+	}
+
+	return infra.ValidateHandler(cfg)
+}
+
+func (p *Provider) Destroy(cfg *config.Config) error {
+	if err := infra.ValidatePlatformDir(p.Name()); err != nil {
+		return err
+	}
+
+	_, err := p.call(cfg, Request{Command: CommandDestroy})
+	return err
+}
+
+func (p *Provider) Outputs() []string {
+	// Deploy prints the outputs the plugin returns
+	return nil
+}
+
+func (p *Provider) Status(cfg *config.Config) error {
+	if err := infra.ValidatePlatformDir(p.Name()); err != nil {
+		return err
+	}
+
+	response, err := p.call(cfg, Request{Command: CommandStatus})
+	if err != nil {
+		return err
+	}
+
+	if response.Status != "" {
+		fmt.Printf("status: %s\n", response.Status)
+	}
+	printOutputs(response.Outputs)
+
+	return nil
+}
+
+// call fills in the request's platform, project and, once added, settings
+// before sending it to the plugin
+func (p *Provider) call(cfg *config.Config, request Request) (*Response, error) {
+	request.Platform = p.name
+	request.Project = newProject(cfg)
+
+	if request.Command != CommandAdd {
+		settingsPath := filepath.Join(infra.GetPlatformTerraformDir(p.Name()), settingsFile)
+		settings, err := os.ReadFile(settingsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", settingsPath, err)
+		}
+		request.Settings = settings
+	}
+
+	return call(p.path, request)
+}
+
+func printOutputs(outputs map[string]interface{}) {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if value := outputs[name]; value != nil && value != "" {
+			fmt.Printf("%s: %v\n", name, value)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package platform
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/codeupify/upify/internal/lang"
+)
+
+// AskString prompts for value unless it was already set with a flag
+func AskString(value *string, message string, defaultValue string) error {
+	if *value != "" {
+		return nil
+	}
+
+	question := &survey.Input{
+		Message: message,
+		Default: defaultValue,
+	}
+	return survey.AskOne(question, value)
+}
+
+// AskRuntime prompts for one of the provider's runtimes for the language
+// unless value was already set with a flag
+func AskRuntime(value *string, provider Provider, language lang.Language) error {
+	if *value != "" {
+		return nil
+	}
+
+	runtimes := provider.Runtimes(language)
+	if len(runtimes) == 0 {
+		return fmt.Errorf("CLI doesn't support the specified language yet for %s: %s", provider.Title(), language)
+	}
+
+	question := &survey.Select{
+		Message: "Choose a runtime:",
+		Options: runtimes,
+	}
+	return survey.AskOne(question, value)
+}
@@ -0,0 +1,111 @@
+package platform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/image"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/spf13/pflag"
+)
+
+// Provider is a deployment target. Each platform package registers one from
+// its init function, and the commands work through the registry, so adding a
+// platform doesn't touch the command code
+type Provider interface {
+	// Name is the platform's name on the command line, e.g. "aws-ecs"
+	Name() Platform
+	// Title is how messages refer to the platform, e.g. "AWS ECS"
+	Title() string
+	// Short describes the platform in `upify platform add --help`
+	Short() string
+	// Flags registers the options of `upify platform add <name>`
+	Flags(flags *pflag.FlagSet)
+	// Add prompts for any required option that wasn't set and writes the
+	// handler section from HandlerCode and the terraform configuration from
+	// Templates. Built-in platforms do both through infra.AddProvider
+	Add(cfg *config.Config) error
+	// Runtimes lists the runtimes offered for a language, none if the
+	// language isn't supported
+	Runtimes(language lang.Language) []string
+	// HandlerCode returns the section Add appends to upify_handler
+	HandlerCode(language lang.Language) (string, error)
+	// Templates returns the environment and module main.tf templates. The
+	// environment's placeholders are filled in from the options
+	Templates() (string, string)
+	// Stage copies the project into dir, ready to be zipped
+	Stage(cfg *config.Config, dir string) error
+	// Deploy deploys the project, or the prebuilt artifact when artifactPath
+	// is set
+	Deploy(cfg *config.Config, artifactPath string) error
+	// Destroy removes what Deploy created
+	Destroy(cfg *config.Config) error
+	// Outputs lists the terraform outputs shown after a deploy
+	Outputs() []string
+}
+
+// ImageProvider is a Provider that can also run the project as a container
+// image (package_type: image)
+type ImageProvider interface {
+	Provider
+	// ImageConfig describes how the image runs the project
+	ImageConfig(cfg *config.Config) image.RuntimeConfig
+}
+
+// StatusProvider is a Provider that reports the state of its deployment
+// itself, instead of through its terraform outputs
+type StatusProvider interface {
+	Provider
+	// Status prints the state of the deployment
+	Status(cfg *config.Config) error
+}
+
+// RollbackProvider is a Provider that keeps earlier releases and can switch
+// back to the one before the current release
+type RollbackProvider interface {
+	Provider
+	// Rollback restores the previous release
+	Rollback(cfg *config.Config) error
+}
+
+var providers = map[Platform]Provider{}
+
+// Register makes a provider available to the commands. It panics if the
+// name is taken, since that can only be a programming error
+func Register(provider Provider) {
+	name := provider.Name()
+	if _, ok := providers[name]; ok {
+		panic(fmt.Sprintf("platform %s is registered twice", name))
+	}
+	providers[name] = provider
+}
+
+// Get returns the provider registered as name
+func Get(name Platform) (Provider, error) {
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported platform: %s (supported: %s)", name, strings.Join(Names(), ", "))
+	}
+	return provider, nil
+}
+
+// Providers returns the registered providers sorted by name
+func Providers() []Provider {
+	result := make([]Provider, 0, len(providers))
+	for _, name := range Names() {
+		result = append(result, providers[Platform(name)])
+	}
+	return result
+}
+
+// Names returns the names of the registered providers, sorted
+func Names() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,17 @@
+package platform
+
+import "github.com/codeupify/upify/internal/lang"
+
+// ContainerRuntimes lists the runtimes of platforms running images or
+// servers, where the runtime only has to match the interpreter the image is
+// built with or the host provides
+func ContainerRuntimes(language lang.Language) []string {
+	switch language {
+	case lang.Python:
+		return []string{"python3.9", "python3.10", "python3.11", "python3.12"}
+	case lang.JavaScript, lang.TypeScript:
+		return []string{"nodejs18", "nodejs20", "nodejs22"}
+	default:
+		return []string{}
+	}
+}
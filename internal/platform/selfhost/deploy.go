@@ -0,0 +1,239 @@
+package selfhost
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/lang/node"
+	"github.com/codeupify/upify/internal/lang/python"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/codeupify/upify/internal/platform/adapters"
+)
+
+const (
+	// installScript is run by deploy.sh from the release directory on the
+	// host to install the dependencies
+	installScript = "upify_install.sh"
+	// startScript is the systemd service's command
+	startScript = "upify_start.sh"
+	// buildScript is the package.json script that builds Node.js projects
+	buildScript = "upify-build"
+)
+
+// Deploy applies the platform's terraform configuration, which copies the
+// artifact to the host over SSH and installs it there. When artifactPath is
+// empty the project is staged and zipped first, otherwise the prebuilt
+// artifact is deployed as is
+func Deploy(cfg *config.Config, artifactPath string) error {
+	if cfg.ImagePackaging() {
+		return fmt.Errorf("package_type image is not supported on selfhost, which installs the zip on the host")
+	}
+
+	if artifactPath == "" {
+		if err := infra.PreDeployValidate(cfg, platform.SelfHost); err != nil {
+			return err
+		}
+	} else if err := infra.ValidateTerraformDir(platform.SelfHost); err != nil {
+		return err
+	}
+
+	if err := infra.WriteEnvironmentVariables(platform.SelfHost); err != nil {
+		return err
+	}
+
+	if artifactPath == "" {
+		tempDir, err := os.MkdirTemp("", "selfhost_deployment_")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		stagingDir := filepath.Join(tempDir, "source")
+		if err := Stage(cfg, stagingDir); err != nil {
+			return err
+		}
+
+		artifactPath = filepath.Join(tempDir, "source.zip")
+		if err := infra.CreateArtifact(cfg, platform.SelfHost, stagingDir, artifactPath); err != nil {
+			return err
+		}
+	}
+
+	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.SelfHost))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	vars := map[string]string{
+		"source_zip_path": artifactPath,
+	}
+
+	ctx := context.Background()
+	if err := terraformManager.Apply(ctx, vars); err != nil {
+		return err
+	}
+
+	return infra.RecordDeploy(cfg, platform.SelfHost, artifactPath, "")
+}
+
+// Stage copies the project into dir, declares the adapters alongside its
+// dependencies and writes the scripts that install and start it on the host.
+// Dependencies are installed on the host, not locally, so that native
+// packages match its platform
+func Stage(cfg *config.Config, dir string) error {
+	err := infra.CopySource(cfg, dir)
+	if err != nil {
+		return err
+	}
+
+	runtime := infra.GetPlatformRuntime(platform.SelfHost)
+
+	var install, start string
+	switch cfg.Language {
+	case lang.Python:
+		install, start, err = stagePython(cfg, dir, runtime)
+	case lang.JavaScript, lang.TypeScript:
+		install, start, err = stageNode(cfg, dir, runtime)
+	default:
+		return fmt.Errorf("unsupported language: %s", cfg.Language)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, installScript), []byte(install), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %v", installScript, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, startScript), []byte(start), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %v", startScript, err)
+	}
+
+	return nil
+}
+
+// stagePython writes requirements.txt with the adapters added, returning
+// the install and start scripts. gunicorn takes the listening socket from
+// systemd and the worker count from WEB_CONCURRENCY
+func stagePython(cfg *config.Config, dir string, runtime string) (string, string, error) {
+	if _, err := python.WriteRequirements(dir, cfg.GetSourceDir(), cfg.PackageManager); err != nil {
+		return "", "", fmt.Errorf("failed to write requirements.txt: %v", err)
+	}
+
+	var results []adapters.Result
+	for _, adapter := range adapters.For(platform.SelfHost, cfg) {
+		declared, added, err := python.AddRequirement(dir, adapter.Package, adapter.Version)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to update requirements.txt: %v", err)
+		}
+		results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: declared, Added: added})
+	}
+	adapters.PrintReport(results)
+
+	interpreter := runtime
+	if interpreter == "" {
+		interpreter = "python3"
+	}
+
+	install := fmt.Sprintf(`#!/bin/sh
+# Installs the dependencies into a virtualenv, run from the release directory
+set -e
+if ! command -v %[1]s >/dev/null 2>&1; then
+    echo "%[1]s is not installed on this host" >&2
+    exit 1
+fi
+%[1]s -m venv .venv
+.venv/bin/pip install --disable-pip-version-check --quiet -r requirements.txt
+`, interpreter)
+
+	start := `#!/bin/sh
+exec .venv/bin/gunicorn --access-logfile - upify_handler:app
+`
+
+	return install, start, nil
+}
+
+// stageNode declares the adapters in package.json along with a build script,
+// returning the install and start scripts. The project is built on the host
+// with its devDependencies, which are pruned afterwards
+func stageNode(cfg *config.Config, dir string, runtime string) (string, string, error) {
+	pkgJsonPath := filepath.Join(dir, "package.json")
+	pkgJson, err := node.ParsePackageJSON(pkgJsonPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse package.json: %v", err)
+	}
+
+	var results []adapters.Result
+	for _, adapter := range adapters.For(platform.SelfHost, cfg) {
+		if declared, ok := pkgJson.Dependencies[adapter.Package]; ok {
+			results = append(results, adapters.Result{Adapter: adapter, InstalledVersion: declared})
+			continue
+		}
+
+		// Updating the lockfile would need the registry
+		if cfg.Offline && node.HasLockfile(dir, cfg.PackageManager) {
+			return "", "", adapters.OfflineError(adapter)
+		}
+
+		node.AddPackageToPackageJSON(pkgJson, adapter.Package, adapter.Version)
+		if err := node.UpdateLockfile(dir, adapter.Package, adapter.Version, cfg.PackageManager); err != nil {
+			return "", "", err
+		}
+		results = append(results, adapters.Result{Adapter: adapter, Added: true})
+	}
+	adapters.PrintReport(results)
+
+	handler := "upify_handler.js"
+	if cfg.Language == lang.TypeScript {
+		compileCommand, err := node.PrepareTypeScript(dir)
+		if err != nil {
+			return "", "", err
+		}
+		if pkgJson.Scripts != nil && pkgJson.Scripts["build"] != "" {
+			compileCommand = node.RunScriptCommand(cfg.PackageManager, "build") + " && " + compileCommand
+		}
+		node.AddScriptToPackageJSON(pkgJson, buildScript, compileCommand)
+
+		handler, err = node.CompiledHandler(dir)
+		if err != nil {
+			return "", "", err
+		}
+	} else if pkgJson.Scripts != nil && pkgJson.Scripts["build"] != "" {
+		node.AddScriptToPackageJSON(pkgJson, buildScript, node.RunScriptCommand(cfg.PackageManager, "build"))
+	}
+
+	if err := node.WritePackageJSON(pkgJsonPath, pkgJson); err != nil {
+		return "", "", err
+	}
+
+	locked := node.HasLockfile(dir, cfg.PackageManager)
+	commands := []string{node.InstallCommand(dir, cfg.PackageManager, locked)}
+	if pkgJson.Scripts != nil && pkgJson.Scripts[buildScript] != "" {
+		commands = append(commands, node.RunScriptCommand(cfg.PackageManager, buildScript))
+	}
+	commands = append(commands, node.PruneCommand(dir, cfg.PackageManager, locked))
+
+	install := fmt.Sprintf(`#!/bin/sh
+# Installs the dependencies and builds the project, run from the release
+# directory
+set -e
+major=$(node -p 'process.versions.node.split(".")[0]')
+if [ -n "%[1]s" ] && [ "$major" != "%[1]s" ]; then
+    echo "the host runs Node.js $major, but the runtime is nodejs%[1]s" >&2
+    exit 1
+fi
+%[2]s
+`, strings.TrimPrefix(runtime, "nodejs"), strings.Join(commands, "\n"))
+
+	start := fmt.Sprintf(`#!/bin/sh
+exec node %s
+`, handler)
+
+	return install, start, nil
+}
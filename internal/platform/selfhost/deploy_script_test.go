@@ -0,0 +1,289 @@
+package selfhost
+
+import (
+	"archive/zip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scriptHost is a fake host for deploy.sh: the app directory and the unit
+// directory are temporary, and systemctl only records its arguments. The
+// health check reaches a test server standing in for the app
+type scriptHost struct {
+	t          *testing.T
+	dir        string
+	appDir     string
+	systemdDir string
+	env        []string
+	port       string
+}
+
+func newScriptHost(t *testing.T) *scriptHost {
+	t.Helper()
+
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	_, unzipErr := exec.LookPath("unzip")
+	_, pythonErr := exec.LookPath("python3")
+	if unzipErr != nil && pythonErr != nil {
+		t.Skip("unzip or python3 is needed to unpack releases")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	h := &scriptHost{
+		t:          t,
+		dir:        dir,
+		appDir:     filepath.Join(dir, "opt", "app"),
+		systemdDir: filepath.Join(dir, "systemd"),
+		port:       serverURL.Port(),
+	}
+
+	bin := filepath.Join(dir, "bin")
+	for _, d := range []string{bin, h.systemdDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fakes := map[string]string{
+		"systemctl":  "#!/bin/sh\necho \"$*\" >> \"" + filepath.Join(dir, "systemctl.log") + "\"\n",
+		"journalctl": "#!/bin/sh\n",
+		"useradd":    "#!/bin/sh\necho \"useradd $*\" >&2\nexit 1\n",
+	}
+	for name, content := range fakes {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h.env = append(os.Environ(),
+		"PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"UPIFY_SYSTEMD_DIR="+h.systemdDir,
+	)
+	return h
+}
+
+// upload writes what the module uploads for a release, whose install script
+// runs install
+func (h *scriptHost) upload(release string, install string) string {
+	h.t.Helper()
+
+	serviceUser, err := user.Current()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	upload := filepath.Join(h.dir, "upload-"+release)
+	if err := os.MkdirAll(upload, 0700); err != nil {
+		h.t.Fatal(err)
+	}
+
+	settings := fmt.Sprintf(`UPIFY_NAME='app'
+UPIFY_RUNTIME='python3.12'
+UPIFY_APP_DIR='%s'
+UPIFY_SERVICE_USER='%s'
+UPIFY_PORT='%s'
+UPIFY_WORKERS='2'
+UPIFY_HEALTH_CHECK_PATH='/'
+UPIFY_KEEP_RELEASES='5'
+UPIFY_RELEASE='%s'
+`, h.appDir, serviceUser.Username, h.port, release)
+
+	files := map[string]string{
+		"settings":  settings,
+		"env":       "SECRET=\"value\"\n",
+		"deploy.sh": DeployScript,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(upload, name), []byte(content), 0600); err != nil {
+			h.t.Fatal(err)
+		}
+	}
+
+	out, err := os.Create(filepath.Join(upload, "source.zip"))
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	for name, content := range map[string]string{
+		installScript: "#!/bin/sh\n" + install + "\n",
+		startScript:   "#!/bin/sh\nexec true\n",
+		"release":     release + "\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			h.t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		h.t.Fatal(err)
+	}
+
+	return upload
+}
+
+func (h *scriptHost) run(script string, args ...string) (string, error) {
+	cmd := exec.Command("bash", append([]string{script}, args...)...)
+	cmd.Env = h.env
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+func (h *scriptHost) deploy(release string, install string) (string, error) {
+	upload := h.upload(release, install)
+	return h.run(filepath.Join(upload, "deploy.sh"), "deploy", upload)
+}
+
+func (h *scriptHost) rollback() (string, error) {
+	return h.run(filepath.Join(h.appDir, "upify-deploy.sh"), "rollback")
+}
+
+// current returns the release the current symlink points at
+func (h *scriptHost) current() string {
+	h.t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(h.appDir, "current", "release"))
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func TestDeployScript(t *testing.T) {
+	h := newScriptHost(t)
+
+	for _, release := range []string{"000000000001", "000000000002"} {
+		output, err := h.deploy(release, "touch installed")
+		if err != nil {
+			t.Fatalf("deploy %s failed: %v\n%s", release, err, output)
+		}
+		if !strings.Contains(output, "is live on port "+h.port) {
+			t.Errorf("deploy %s output doesn't report the release:\n%s", release, output)
+		}
+		if _, err := os.Stat(filepath.Join(h.dir, "upload-"+release)); !os.IsNotExist(err) {
+			t.Errorf("upload of %s wasn't removed", release)
+		}
+	}
+
+	if got := h.current(); got != "000000000002" {
+		t.Errorf("current release = %s, want 000000000002", got)
+	}
+	if _, err := os.Stat(filepath.Join(h.appDir, "current", "installed")); err != nil {
+		t.Errorf("install script didn't run: %v", err)
+	}
+
+	socket, err := os.ReadFile(filepath.Join(h.systemdDir, "app.socket"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(socket), "ListenStream="+h.port+"\n") {
+		t.Errorf("socket unit doesn't listen on %s:\n%s", h.port, socket)
+	}
+	service, err := os.ReadFile(filepath.Join(h.systemdDir, "app.service"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(service), "ExecStart=/bin/sh "+filepath.Join(h.appDir, "current", startScript)+"\n") {
+		t.Errorf("service unit doesn't start the current release:\n%s", service)
+	}
+
+	env, err := os.ReadFile(filepath.Join(h.appDir, "shared", "env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(env) != "SECRET=\"value\"\n" {
+		t.Errorf("env = %q", env)
+	}
+	if info, err := os.Stat(filepath.Join(h.appDir, "shared", "env")); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("env isn't readable by the service user only: %v %v", info.Mode(), err)
+	}
+
+	// A release whose dependencies fail to install is discarded
+	output, err := h.deploy("000000000003", "exit 1")
+	if err == nil {
+		t.Fatalf("deploy with a failing install succeeded:\n%s", output)
+	}
+	if !strings.Contains(output, "keeping the current release") {
+		t.Errorf("failed deploy output:\n%s", output)
+	}
+	if got := h.current(); got != "000000000002" {
+		t.Errorf("current release after a failed install = %s, want 000000000002", got)
+	}
+	releases, err := os.ReadDir(filepath.Join(h.appDir, "releases"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 2 {
+		t.Errorf("got %d releases after a failed install, want 2", len(releases))
+	}
+
+	output, err = h.rollback()
+	if err != nil {
+		t.Fatalf("rollback failed: %v\n%s", err, output)
+	}
+	if got := h.current(); got != "000000000001" {
+		t.Errorf("current release after the rollback = %s, want 000000000001", got)
+	}
+
+	systemctl, err := os.ReadFile(filepath.Join(h.dir, "systemctl.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := strings.Split(strings.TrimSpace(string(systemctl)), "\n")
+	if last := calls[len(calls)-2]; last != "restart app.service" {
+		t.Errorf("rollback ran systemctl %q, want restart app.service", last)
+	}
+
+	// There's nothing older than the first release
+	output, err = h.rollback()
+	if err == nil {
+		t.Fatalf("second rollback succeeded:\n%s", output)
+	}
+	if !strings.Contains(output, "No release older than") {
+		t.Errorf("second rollback output:\n%s", output)
+	}
+	if got := h.current(); got != "000000000001" {
+		t.Errorf("current release after the second rollback = %s, want 000000000001", got)
+	}
+}
+
+func TestDeployScriptWritableUpload(t *testing.T) {
+	h := newScriptHost(t)
+
+	upload := h.upload("000000000001", "touch installed")
+	if err := os.Chmod(upload, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := h.run(filepath.Join(upload, "deploy.sh"), "deploy", upload)
+	if err == nil {
+		t.Fatalf("deploy from a world-writable upload succeeded:\n%s", output)
+	}
+	if !strings.Contains(output, "only its owner can write to") {
+		t.Errorf("deploy output:\n%s", output)
+	}
+	if _, err := os.Stat(filepath.Join(h.appDir, "releases")); !os.IsNotExist(err) {
+		t.Errorf("deploy from a world-writable upload created releases: %v", err)
+	}
+}
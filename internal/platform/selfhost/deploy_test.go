@@ -0,0 +1,166 @@
+package selfhost
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/lang/node"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStagePython(t *testing.T) {
+	tests := []struct {
+		name        string
+		runtime     string
+		interpreter string
+	}{
+		{name: "runtime", runtime: "python3.12", interpreter: "python3.12"},
+		{name: "no runtime", runtime: "", interpreter: "python3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"requirements.txt": "flask==3.0.3\n"})
+
+			cfg := &config.Config{Name: "app", Language: lang.Python, PackageManager: lang.Pip, SourceDir: dir}
+			install, start, err := stagePython(cfg, dir, tt.runtime)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range []string{
+				"command -v " + tt.interpreter + " ",
+				"\n" + tt.interpreter + " -m venv .venv\n",
+				".venv/bin/pip install --disable-pip-version-check --quiet -r requirements.txt\n",
+			} {
+				if !strings.Contains(install, want) {
+					t.Errorf("install script is missing %q:\n%s", want, install)
+				}
+			}
+
+			if want := "exec .venv/bin/gunicorn --access-logfile - upify_handler:app\n"; !strings.HasSuffix(start, want) {
+				t.Errorf("start script = %q, want it to run %q", start, want)
+			}
+
+			requirements, err := os.ReadFile(filepath.Join(dir, "requirements.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(requirements), "gunicorn==23.0.0") {
+				t.Errorf("requirements.txt doesn't declare gunicorn:\n%s", requirements)
+			}
+		})
+	}
+}
+
+func TestStageNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		language lang.Language
+		runtime  string
+		files    map[string]string
+		commands []string
+		handler  string
+		script   string
+	}{
+		{
+			name:     "javascript",
+			language: lang.JavaScript,
+			runtime:  "nodejs20",
+			files:    map[string]string{"package.json": `{"name": "app", "dependencies": {"express": "^4.21.1"}}`},
+			commands: []string{"npm install --include=dev", "npm prune --omit=dev"},
+			handler:  "upify_handler.js",
+		},
+		{
+			name:     "javascript build",
+			language: lang.JavaScript,
+			runtime:  "nodejs22",
+			files:    map[string]string{"package.json": `{"name": "app", "scripts": {"build": "webpack"}}`},
+			commands: []string{"npm install --include=dev", "npm run upify-build", "npm prune --omit=dev"},
+			handler:  "upify_handler.js",
+			script:   "npm run build",
+		},
+		{
+			name:     "typescript",
+			language: lang.TypeScript,
+			runtime:  "nodejs20",
+			files:    map[string]string{"package.json": `{"name": "app"}`},
+			commands: []string{"npm install --include=dev", "npm run upify-build", "npm prune --omit=dev"},
+			handler:  "upify_handler.js",
+			script:   "tsc -p tsconfig.upify.json",
+		},
+		{
+			name:     "typescript build",
+			language: lang.TypeScript,
+			runtime:  "nodejs20",
+			files:    map[string]string{"package.json": `{"name": "app", "scripts": {"build": "node codegen.js"}}`},
+			commands: []string{"npm install --include=dev", "npm run upify-build", "npm prune --omit=dev"},
+			handler:  "upify_handler.js",
+			script:   "npm run build && tsc -p tsconfig.upify.json",
+		},
+		{
+			name:     "typescript outDir",
+			language: lang.TypeScript,
+			runtime:  "nodejs20",
+			files: map[string]string{
+				"package.json": `{"name": "app"}`,
+				"tsconfig.json": `{
+					// Compiled into dist
+					"compilerOptions": {"outDir": "./dist"}
+				}`,
+			},
+			commands: []string{"npm install --include=dev", "npm run upify-build", "npm prune --omit=dev"},
+			handler:  "dist/upify_handler.js",
+			script:   "tsc -p tsconfig.upify.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			cfg := &config.Config{Name: "app", Language: tt.language, PackageManager: lang.Npm}
+			install, start, err := stageNode(cfg, dir, tt.runtime)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			major := strings.TrimPrefix(tt.runtime, "nodejs")
+			if want := `if [ -n "` + major + `" ] && [ "$major" != "` + major + `" ]; then`; !strings.Contains(install, want) {
+				t.Errorf("install script doesn't check for Node.js %s:\n%s", major, install)
+			}
+			if want := "\n" + strings.Join(tt.commands, "\n") + "\n"; !strings.HasSuffix(install, want) {
+				t.Errorf("install script doesn't end with %q:\n%s", want, install)
+			}
+
+			if want := "exec node " + tt.handler + "\n"; !strings.HasSuffix(start, want) {
+				t.Errorf("start script = %q, want it to run %q", start, want)
+			}
+
+			pkgJson, err := node.ParsePackageJSON(filepath.Join(dir, "package.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pkgJson.Scripts[buildScript] != tt.script {
+				t.Errorf("%s script = %q, want %q", buildScript, pkgJson.Scripts[buildScript], tt.script)
+			}
+			if _, ok := pkgJson.Dependencies["express"]; !ok {
+				t.Errorf("package.json doesn't declare express: %v", pkgJson.Dependencies)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package selfhost
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// gunicorn serves upify_handler:app, this only runs the app without it
+const pythonCode = `if os.getenv("UPIFY_DEPLOY_PLATFORM") == "selfhost" and __name__ == "__main__":
+    app.run(host="0.0.0.0", port=int(os.getenv("PORT", "8080")))`
+
+// systemd hands over the socket it listens on as fd 3, holding connections
+// while the app restarts
+const nodeCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === 'selfhost' && require.main === module) {
+    let expressApp = {APP_VAR};
+    if ({APP_VAR} && {APP_VAR}['app']) {
+        expressApp = {APP_VAR}['app'];
+    }
+    const address = process.env.LISTEN_FDS ? { fd: 3 } : parseInt(process.env.PORT || '8080', 10);
+    const server = expressApp.listen(address, () => console.log('Listening on port ' + process.env.PORT));
+    process.on('SIGTERM', () => server.close(() => process.exit(0)));
+}`
+
+const typescriptCode = `if (process.env.UPIFY_DEPLOY_PLATFORM === 'selfhost' && require.main === module) {
+    let expressApp = {APP_VAR};
+    if ({APP_VAR} && {APP_VAR}['app']) {
+        expressApp = {APP_VAR}['app'];
+    }
+    const address: any = process.env.LISTEN_FDS ? { fd: 3 } : parseInt(process.env.PORT || '8080', 10);
+    const server = expressApp.listen(address, () => console.log('Listening on port ' + process.env.PORT));
+    process.on('SIGTERM', () => server.close(() => process.exit(0)));
+}`
+
+// HandlerCode returns the handler section for the language
+func HandlerCode(language lang.Language) (string, error) {
+	switch language {
+	case lang.Python:
+		return pythonCode, nil
+	case lang.JavaScript:
+		return nodeCode, nil
+	case lang.TypeScript:
+		return typescriptCode, nil
+	default:
+		return "", fmt.Errorf("unsupported language: %s", language)
+	}
+}
+
+//go:embed templates/main.tmpl
+var MainTemplate string
+
+//go:embed templates/main.module.tmpl
+var MainModuleTemplate string
+
+//go:embed templates/deploy.sh
+var DeployScript string
+
+// unixName is the format of the systemd unit and service user names
+var unixName = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// hostname is the format of host names and IP addresses, which end up in the
+// shell settings on the host and on the ssh command line, where a leading -
+// would be read as an option
+var hostname = regexp.MustCompile(`^[A-Za-z0-9.:\[\]][A-Za-z0-9.:\[\]-]*$`)
+
+// urlPath is the format of health check paths, which also end up there
+var urlPath = regexp.MustCompile(`^/[A-Za-z0-9._~!$&()*+,;=:@%/?-]*$`)
+
+// Host holds the machine to deploy to over SSH and how the app runs there,
+// written to main.tf where it can be changed later. An empty SSHKey uses the
+// SSH agent
+type Host struct {
+	Address         string
+	SSHUser         string
+	SSHPort         int
+	SSHKey          string
+	ServiceUser     string
+	AppPort         int
+	Workers         int
+	HealthCheckPath string
+}
+
+func (h Host) validate(name string) error {
+	if !unixName.MatchString(name) {
+		return fmt.Errorf("project name %q isn't a valid systemd unit name here (lowercase letters, digits, _ and -, at most 32)", name)
+	}
+	if !hostname.MatchString(h.Address) {
+		return fmt.Errorf("invalid host: %q", h.Address)
+	}
+	if !unixName.MatchString(h.SSHUser) {
+		return fmt.Errorf("invalid SSH user: %q", h.SSHUser)
+	}
+	if !unixName.MatchString(h.ServiceUser) {
+		return fmt.Errorf("invalid service user: %q", h.ServiceUser)
+	}
+	if h.SSHPort < 1 || h.SSHPort > 65535 {
+		return fmt.Errorf("invalid SSH port: %d", h.SSHPort)
+	}
+	if h.AppPort < 1 || h.AppPort > 65535 {
+		return fmt.Errorf("invalid app port: %d", h.AppPort)
+	}
+	if h.Workers < 1 {
+		return fmt.Errorf("workers must be at least 1, got %d", h.Workers)
+	}
+	if !urlPath.MatchString(h.HealthCheckPath) {
+		return fmt.Errorf("invalid health check path: %q", h.HealthCheckPath)
+	}
+	return nil
+}
+
+// writeDeployScript writes deploy.sh into the module, which uploads it
+// next to the artifact. An existing one is kept along with the module
+func writeDeployScript() error {
+	scriptPath := filepath.Join(".upify", "modules", string(platform.SelfHost), "deploy.sh")
+	if _, err := os.Stat(scriptPath); err == nil {
+		return nil
+	}
+
+	fmt.Printf("Creating %s...\n", scriptPath)
+	if err := os.WriteFile(scriptPath, []byte(DeployScript), 0755); err != nil {
+		return fmt.Errorf("failed to write deploy.sh: %v", err)
+	}
+
+	return nil
+}
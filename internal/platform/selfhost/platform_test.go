@@ -0,0 +1,62 @@
+package selfhost
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHostValidate(t *testing.T) {
+	valid := Host{
+		Address:         "203.0.113.10",
+		SSHUser:         "deploy",
+		SSHPort:         22,
+		ServiceUser:     "app",
+		AppPort:         8080,
+		Workers:         2,
+		HealthCheckPath: "/healthz",
+	}
+
+	tests := []struct {
+		name    string
+		project string
+		change  func(h *Host)
+		err     string
+	}{
+		{name: "valid", project: "app"},
+		{name: "hostname", project: "app", change: func(h *Host) { h.Address = "app.example.com" }},
+		{name: "ipv6", project: "app", change: func(h *Host) { h.Address = "[2001:db8::1]" }},
+		{name: "health check query", project: "app", change: func(h *Host) { h.HealthCheckPath = "/health?full=1" }},
+		{name: "project name", project: "My App", err: "project name"},
+		{name: "long project name", project: strings.Repeat("a", 33), err: "project name"},
+		{name: "empty host", project: "app", change: func(h *Host) { h.Address = "" }, err: "invalid host"},
+		{name: "host injection", project: "app", change: func(h *Host) { h.Address = "example.com; rm -rf /" }, err: "invalid host"},
+		{name: "host option", project: "app", change: func(h *Host) { h.Address = "-oProxyCommand" }, err: "invalid host"},
+		{name: "ssh user", project: "app", change: func(h *Host) { h.SSHUser = "Deploy" }, err: "invalid SSH user"},
+		{name: "service user", project: "app", change: func(h *Host) { h.ServiceUser = "app'" }, err: "invalid service user"},
+		{name: "ssh port", project: "app", change: func(h *Host) { h.SSHPort = 0 }, err: "invalid SSH port"},
+		{name: "app port", project: "app", change: func(h *Host) { h.AppPort = 70000 }, err: "invalid app port"},
+		{name: "workers", project: "app", change: func(h *Host) { h.Workers = 0 }, err: "workers"},
+		{name: "relative health check", project: "app", change: func(h *Host) { h.HealthCheckPath = "healthz" }, err: "invalid health check path"},
+		{name: "quoted health check", project: "app", change: func(h *Host) { h.HealthCheckPath = "/'$(id)'" }, err: "invalid health check path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := valid
+			if tt.change != nil {
+				tt.change(&host)
+			}
+
+			err := host.validate(tt.project)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.err)
+			}
+		})
+	}
+}
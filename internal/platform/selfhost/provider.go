@@ -0,0 +1,114 @@
+package selfhost
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/lang"
+	"github.com/codeupify/upify/internal/platform"
+	"github.com/spf13/pflag"
+)
+
+type provider struct {
+	runtime string
+	host    Host
+}
+
+func init() {
+	platform.Register(&provider{})
+}
+
+func (p *provider) Name() platform.Platform {
+	return platform.SelfHost
+}
+
+func (p *provider) Title() string {
+	return "self-hosted server"
+}
+
+func (p *provider) Short() string {
+	return "Add self-hosted server configuration (SSH and systemd)"
+}
+
+func (p *provider) Flags(flags *pflag.FlagSet) {
+	flags.StringVar(&p.runtime, "runtime", "", "Language runtime")
+	flags.StringVar(&p.host.Address, "host", "", "Address of the server")
+	flags.StringVar(&p.host.SSHUser, "ssh-user", "root", "SSH user, root or a user with passwordless sudo")
+	flags.IntVar(&p.host.SSHPort, "ssh-port", 22, "SSH port")
+	flags.StringVar(&p.host.SSHKey, "ssh-key", "", "Path to the SSH private key (default the SSH agent)")
+	flags.StringVar(&p.host.ServiceUser, "service-user", "", "User the app runs as, created if missing (default the project name)")
+	flags.IntVar(&p.host.AppPort, "port", 8080, "Port the app listens on")
+	flags.IntVar(&p.host.Workers, "workers", 2, "Number of gunicorn workers (Python only)")
+	flags.StringVar(&p.host.HealthCheckPath, "health-check-path", "/", "Path checked before a release is kept")
+}
+
+func (p *provider) Add(cfg *config.Config) error {
+	if err := platform.AskString(&p.host.Address, "Enter the server's address:", ""); err != nil {
+		return err
+	}
+
+	if err := platform.AskRuntime(&p.runtime, p, cfg.Language); err != nil {
+		return err
+	}
+
+	if p.host.ServiceUser == "" {
+		p.host.ServiceUser = cfg.Name
+	}
+
+	if err := p.host.validate(cfg.Name); err != nil {
+		return err
+	}
+
+	err := infra.AddProvider(cfg, p, "selfhost", map[string]string{
+		"{NAME}":              cfg.Name,
+		"{RUNTIME}":           p.runtime,
+		"{HOST}":              p.host.Address,
+		"{SSH_USER}":          p.host.SSHUser,
+		"{SSH_PORT}":          strconv.Itoa(p.host.SSHPort),
+		"{SSH_PRIVATE_KEY}":   p.host.SSHKey,
+		"{SERVICE_USER}":      p.host.ServiceUser,
+		"{APP_PORT}":          strconv.Itoa(p.host.AppPort),
+		"{WORKERS}":           strconv.Itoa(p.host.Workers),
+		"{HEALTH_CHECK_PATH}": p.host.HealthCheckPath,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeDeployScript()
+}
+
+func (p *provider) Runtimes(language lang.Language) []string {
+	return platform.ContainerRuntimes(language)
+}
+
+func (p *provider) HandlerCode(language lang.Language) (string, error) {
+	return HandlerCode(language)
+}
+
+func (p *provider) Templates() (string, string) {
+	return MainTemplate, MainModuleTemplate
+}
+
+func (p *provider) Stage(cfg *config.Config, dir string) error {
+	return Stage(cfg, dir)
+}
+
+func (p *provider) Deploy(cfg *config.Config, artifactPath string) error {
+	return Deploy(cfg, artifactPath)
+}
+
+func (p *provider) Rollback(cfg *config.Config) error {
+	return Rollback(cfg)
+}
+
+// Destroy can't go through terraform, which only holds the last upload
+func (p *provider) Destroy(cfg *config.Config) error {
+	return fmt.Errorf("selfhost doesn't remove the app from the server; run `sudo /opt/%s/upify-deploy.sh uninstall` there instead", cfg.Name)
+}
+
+func (p *provider) Outputs() []string {
+	return []string{"service_url"}
+}
@@ -0,0 +1,102 @@
+package selfhost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codeupify/upify/internal/config"
+	"github.com/codeupify/upify/internal/infra"
+	"github.com/codeupify/upify/internal/platform"
+)
+
+// connection is the module's ssh output, the settings the release resource
+// connects with
+type connection struct {
+	Host       string `json:"host"`
+	User       string `json:"user"`
+	Port       int    `json:"port"`
+	PrivateKey string `json:"private_key"`
+	AppDir     string `json:"app_dir"`
+}
+
+// Rollback runs the deploy script installed on the host, which switches the
+// current symlink back to the previous release and restarts the service
+func Rollback(cfg *config.Config) error {
+	if err := infra.ValidateTerraformDir(platform.SelfHost); err != nil {
+		return err
+	}
+
+	terraformManager, err := infra.NewTerraformManager(infra.GetPlatformTerraformDir(platform.SelfHost))
+	if err != nil {
+		return fmt.Errorf("failed to create terraform manager: %v", err)
+	}
+
+	outputs, err := terraformManager.Output(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to read terraform outputs: %v", err)
+	}
+
+	output, ok := outputs["ssh"]
+	if !ok {
+		return fmt.Errorf("main.tf has no ssh output, it was written by an older version of upify; run `sudo /opt/%s/upify-deploy.sh rollback` on the server instead", cfg.Name)
+	}
+
+	var conn connection
+	if err := json.Unmarshal(output.Value, &conn); err != nil {
+		return fmt.Errorf("failed to parse the ssh output: %v", err)
+	}
+
+	args, err := sshArgs(conn)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rolling back %s on %s...\n", cfg.Name, conn.Host)
+	cmd := exec.Command("ssh", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("rollback failed: %v", err)
+	}
+
+	return nil
+}
+
+// sshArgs returns the ssh arguments that run `upify-deploy.sh rollback` on
+// the host, through sudo unless the user is root
+func sshArgs(conn connection) ([]string, error) {
+	if !hostname.MatchString(conn.Host) {
+		return nil, fmt.Errorf("invalid host: %q", conn.Host)
+	}
+	if !unixName.MatchString(conn.User) {
+		return nil, fmt.Errorf("invalid SSH user: %q", conn.User)
+	}
+	if !strings.HasPrefix(conn.AppDir, "/") || strings.ContainsAny(conn.AppDir, "'\n") {
+		return nil, fmt.Errorf("invalid app directory: %q", conn.AppDir)
+	}
+
+	args := []string{"-p", strconv.Itoa(conn.Port)}
+	if conn.PrivateKey != "" {
+		key := conn.PrivateKey
+		if strings.HasPrefix(key, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("unable to determine home directory: %v", err)
+			}
+			key = filepath.Join(home, key[2:])
+		}
+		args = append(args, "-i", key, "-o", "IdentitiesOnly=yes")
+	}
+
+	script := "'" + conn.AppDir + "/upify-deploy.sh'"
+	command := fmt.Sprintf(`if [ "$(id -u)" -eq 0 ]; then bash %[1]s rollback; else sudo -n bash %[1]s rollback; fi`, script)
+
+	// ssh takes IPv6 addresses without the brackets terraform needs
+	return append(args, conn.User+"@"+strings.Trim(conn.Host, "[]"), command), nil
+}
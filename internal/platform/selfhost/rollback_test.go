@@ -0,0 +1,66 @@
+package selfhost
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSSHArgs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	command := `if [ "$(id -u)" -eq 0 ]; then bash '/opt/app/upify-deploy.sh' rollback; else sudo -n bash '/opt/app/upify-deploy.sh' rollback; fi`
+
+	tests := []struct {
+		name string
+		conn connection
+		want []string
+		err  string
+	}{
+		{
+			name: "agent",
+			conn: connection{Host: "203.0.113.10", User: "root", Port: 22, AppDir: "/opt/app"},
+			want: []string{"-p", "22", "root@203.0.113.10", command},
+		},
+		{
+			name: "key",
+			conn: connection{Host: "app.example.com", User: "deploy", Port: 2222, PrivateKey: "~/.ssh/id_ed25519", AppDir: "/opt/app"},
+			want: []string{"-p", "2222", "-i", filepath.Join(home, ".ssh", "id_ed25519"), "-o", "IdentitiesOnly=yes", "deploy@app.example.com", command},
+		},
+		{
+			name: "ipv6",
+			conn: connection{Host: "[2001:db8::1]", User: "root", Port: 22, AppDir: "/opt/app"},
+			want: []string{"-p", "22", "root@2001:db8::1", command},
+		},
+		{
+			name: "host",
+			conn: connection{Host: "-oProxyCommand", User: "root", Port: 22, AppDir: "/opt/app"},
+			err:  "invalid host",
+		},
+		{
+			name: "app dir",
+			conn: connection{Host: "203.0.113.10", User: "root", Port: 22, AppDir: "/opt/app'; id; '"},
+			err:  "invalid app directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := sshArgs(tt.conn)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want it to mention %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, tt.want) {
+				t.Errorf("args = %q, want %q", args, tt.want)
+			}
+		})
+	}
+}
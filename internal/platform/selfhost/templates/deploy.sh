@@ -0,0 +1,243 @@
+#!/usr/bin/env bash
+# Installs releases of an upify app on this host and runs them under systemd.
+# Uploaded and run as root by `upify deploy selfhost`, and kept in the app
+# directory for rollbacks:
+#
+#   deploy.sh deploy <upload dir>   install the artifact uploaded to <upload dir>
+#   deploy.sh rollback              switch back to the release before the current one,
+#                                   run by `upify rollback selfhost`
+#   deploy.sh uninstall             remove the service and every release
+#
+# Each release is unpacked into releases/<timestamp>-<sha> and its
+# dependencies are installed by the upify_install.sh it ships with. The
+# current symlink is then switched over and the service restarted. systemd
+# owns the listening socket, so connections arriving during the restart wait
+# for the new process rather than being refused. A release that doesn't pass
+# the health check is rolled back.
+set -euo pipefail
+umask 022
+
+# Where the units are written, only changed by the tests
+SYSTEMD_DIR="${UPIFY_SYSTEMD_DIR:-/etc/systemd/system}"
+
+load_settings() {
+    # shellcheck disable=SC1090
+    . "$1"
+    APP_DIR="$UPIFY_APP_DIR"
+    RELEASES="$APP_DIR/releases"
+    SHARED="$APP_DIR/shared"
+    CURRENT="$APP_DIR/current"
+    SOCKET_UNIT="$SYSTEMD_DIR/$UPIFY_NAME.socket"
+    SERVICE_UNIT="$SYSTEMD_DIR/$UPIFY_NAME.service"
+}
+
+ensure_user() {
+    if ! id -u "$UPIFY_SERVICE_USER" >/dev/null 2>&1; then
+        echo "Creating user $UPIFY_SERVICE_USER..."
+        useradd --system --home-dir "$APP_DIR" --no-create-home --shell /usr/sbin/nologin "$UPIFY_SERVICE_USER"
+    fi
+}
+
+extract() {
+    mkdir -p "$2"
+    if command -v unzip >/dev/null 2>&1; then
+        unzip -q "$1" -d "$2"
+    elif command -v python3 >/dev/null 2>&1; then
+        python3 -m zipfile -e "$1" "$2"
+    else
+        echo "unzip or python3 is needed to unpack the release" >&2
+        return 1
+    fi
+}
+
+# write_units writes the socket and service units, noting whether the socket
+# has to be restarted for a new port
+write_units() {
+    local socket service
+    socket="[Unit]
+Description=$UPIFY_NAME socket
+
+[Socket]
+ListenStream=$UPIFY_PORT
+
+[Install]
+WantedBy=sockets.target
+"
+    service="[Unit]
+Description=$UPIFY_NAME
+Requires=$UPIFY_NAME.socket
+After=network.target $UPIFY_NAME.socket
+
+[Service]
+Type=simple
+User=$UPIFY_SERVICE_USER
+WorkingDirectory=$CURRENT
+EnvironmentFile=$SHARED/env
+Environment=UPIFY_DEPLOY_PLATFORM=selfhost PORT=$UPIFY_PORT WEB_CONCURRENCY=$UPIFY_WORKERS
+ExecStart=/bin/sh $CURRENT/upify_start.sh
+TimeoutStopSec=30
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+"
+
+    if [ -f "$SOCKET_UNIT" ] && [ "$(cat "$SOCKET_UNIT")" != "$(printf '%s' "$socket")" ]; then
+        SOCKET_CHANGED=1
+    fi
+    printf '%s' "$socket" > "$SOCKET_UNIT"
+    printf '%s' "$service" > "$SERVICE_UNIT"
+    systemctl daemon-reload
+}
+
+switch_to() {
+    ln -sfn "$1" "$CURRENT.new"
+    mv -T "$CURRENT.new" "$CURRENT"
+}
+
+restart() {
+    if [ "${SOCKET_CHANGED:-0}" = 1 ]; then
+        systemctl stop "$UPIFY_NAME.service" "$UPIFY_NAME.socket"
+    fi
+    systemctl enable --quiet "$UPIFY_NAME.socket" "$UPIFY_NAME.service"
+    systemctl start "$UPIFY_NAME.socket"
+    systemctl restart "$UPIFY_NAME.service"
+}
+
+# http_status prints the status code the app answers the health check with
+http_status() {
+    (
+        exec 3<>"/dev/tcp/127.0.0.1/$UPIFY_PORT"
+        printf 'GET %s HTTP/1.0\r\nHost: localhost\r\nConnection: close\r\n\r\n' "$UPIFY_HEALTH_CHECK_PATH" >&3
+        read -r -t 5 _ status _ <&3
+        echo "$status"
+    ) 2>/dev/null || true
+}
+
+healthy() {
+    for _ in $(seq 1 30); do
+        if systemctl is-active --quiet "$UPIFY_NAME.service"; then
+            case "$(http_status)" in
+                2??|3??) return 0 ;;
+            esac
+        fi
+        sleep 1
+    done
+    return 1
+}
+
+# previous_release prints the newest release older than the current one
+previous_release() {
+    local current
+    current="$(readlink -f "$CURRENT" 2>/dev/null || true)"
+    find "$RELEASES" -mindepth 1 -maxdepth 1 -type d | sort | while read -r release; do
+        if [ -n "$current" ] && [[ "$release" < "$current" ]]; then
+            echo "$release"
+        fi
+    done | tail -n 1
+}
+
+prune_releases() {
+    local current
+    current="$(readlink -f "$CURRENT")"
+    find "$RELEASES" -mindepth 1 -maxdepth 1 -type d | sort -r | tail -n +"$((UPIFY_KEEP_RELEASES + 1))" | while read -r release; do
+        if [ "$release" != "$current" ]; then
+            rm -rf "$release"
+        fi
+    done
+}
+
+deploy() {
+    local release previous
+    # The upload holds the environment variables, so it's removed either way
+    UPLOAD="$1"
+    if [ -L "$UPLOAD" ] || [ ! -d "$UPLOAD" ] || [ -n "$(find "$UPLOAD" -maxdepth 0 -perm /022)" ]; then
+        echo "$UPLOAD isn't a directory only its owner can write to" >&2
+        exit 1
+    fi
+    trap 'rm -rf "$UPLOAD"' EXIT
+    load_settings "$UPLOAD/settings"
+
+    mkdir -p "$RELEASES" "$SHARED"
+    ensure_user
+
+    cp "$UPLOAD/settings" "$SHARED/settings"
+    install -m 0755 "$UPLOAD/deploy.sh" "$APP_DIR/upify-deploy.sh"
+    install -m 0640 -g "$(id -gn "$UPIFY_SERVICE_USER")" "$UPLOAD/env" "$SHARED/env"
+
+    release="$RELEASES/$(date -u +%Y%m%d%H%M%S)-$UPIFY_RELEASE"
+    echo "Unpacking release $(basename "$release")..."
+    extract "$UPLOAD/source.zip" "$release"
+
+    echo "Installing dependencies..."
+    if ! (cd "$release" && UPIFY_RUNTIME="$UPIFY_RUNTIME" sh ./upify_install.sh); then
+        echo "Failed to install dependencies, keeping the current release" >&2
+        rm -rf "$release"
+        exit 1
+    fi
+
+    previous="$(readlink -f "$CURRENT" 2>/dev/null || true)"
+    write_units
+    switch_to "$release"
+
+    echo "Restarting $UPIFY_NAME..."
+    restart
+    if ! healthy; then
+        echo "Release $(basename "$release") failed the health check on $UPIFY_HEALTH_CHECK_PATH" >&2
+        journalctl -u "$UPIFY_NAME.service" -n 50 --no-pager >&2 || true
+        if [ -n "$previous" ] && [ -d "$previous" ]; then
+            echo "Rolling back to $(basename "$previous")..." >&2
+            switch_to "$previous"
+            systemctl restart "$UPIFY_NAME.service"
+        else
+            systemctl stop "$UPIFY_NAME.service"
+        fi
+        rm -rf "$release"
+        exit 1
+    fi
+
+    prune_releases
+    echo "Release $(basename "$release") is live on port $UPIFY_PORT"
+}
+
+rollback() {
+    local dir previous
+    dir="$(cd "$(dirname "$0")" && pwd)"
+    load_settings "$dir/shared/settings"
+
+    previous="$(previous_release)"
+    if [ -z "$previous" ]; then
+        echo "No release older than $(basename "$(readlink -f "$CURRENT")") to roll back to" >&2
+        exit 1
+    fi
+
+    echo "Rolling back to $(basename "$previous")..."
+    switch_to "$previous"
+    systemctl restart "$UPIFY_NAME.service"
+    if ! healthy; then
+        echo "Release $(basename "$previous") failed the health check on $UPIFY_HEALTH_CHECK_PATH" >&2
+        exit 1
+    fi
+}
+
+uninstall() {
+    local dir
+    dir="$(cd "$(dirname "$0")" && pwd)"
+    load_settings "$dir/shared/settings"
+
+    echo "Removing $UPIFY_NAME..."
+    systemctl disable --now --quiet "$UPIFY_NAME.service" "$UPIFY_NAME.socket" || true
+    rm -f "$SERVICE_UNIT" "$SOCKET_UNIT"
+    systemctl daemon-reload
+    rm -rf "$APP_DIR"
+}
+
+case "${1:-}" in
+    deploy) deploy "$2" ;;
+    rollback) rollback ;;
+    uninstall) uninstall ;;
+    *)
+        echo "usage: $0 deploy <upload dir> | rollback | uninstall" >&2
+        exit 2
+        ;;
+esac
@@ -0,0 +1,350 @@
+package sbom
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	PyPI = "pypi"
+	Npm  = "npm"
+)
+
+// Component is a third-party package shipped in an artifact
+type Component struct {
+	Name      string
+	Version   string
+	Ecosystem string
+	Licenses  []string
+	// Path is where the package was found, relative to the staging directory
+	Path string
+	// Declared is set when the package was read from a manifest or lockfile
+	// instead of an installed copy, so its license may be unknown
+	Declared bool
+}
+
+// PURL returns the package URL identifying the component
+func (c Component) PURL() string {
+	name := c.Name
+	switch c.Ecosystem {
+	case Npm:
+		name = strings.Replace(name, "@", "%40", 1)
+	case PyPI:
+		name = strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "-"))
+	}
+
+	purl := "pkg:" + c.Ecosystem + "/" + name
+	if c.Version != "" && exactVersion.MatchString(c.Version) {
+		purl += "@" + c.Version
+	}
+
+	return purl
+}
+
+var (
+	pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+	exactVersion         = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.+_-]*$`)
+	pinnedRequirement    = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*==\s*([^\s;#]+)`)
+)
+
+// Scan lists the packages installed in a staging directory from Python
+// .dist-info metadata and node_modules package.json files. Platforms that
+// install dependencies themselves have nothing installed locally, in which
+// case the dependencies declared in package-lock.json, package.json or
+// requirements.txt are listed instead
+func Scan(stagingDir string) ([]Component, error) {
+	components, err := scanPythonPackages(stagingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeComponents, err := scanNodeModules(stagingDir)
+	if err != nil {
+		return nil, err
+	}
+	components = append(components, nodeComponents...)
+
+	if len(components) == 0 {
+		components, err = scanDeclared(stagingDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Ecosystem != components[j].Ecosystem {
+			return components[i].Ecosystem < components[j].Ecosystem
+		}
+		if components[i].Name != components[j].Name {
+			return components[i].Name < components[j].Name
+		}
+		return components[i].Version < components[j].Version
+	})
+
+	return components, nil
+}
+
+func scanPythonPackages(dir string) ([]Component, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var components []Component
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".dist-info") {
+			continue
+		}
+
+		metadata, err := readPythonMetadata(filepath.Join(dir, entry.Name(), "METADATA"))
+		if err != nil || len(metadata["Name"]) == 0 {
+			continue
+		}
+
+		components = append(components, Component{
+			Name:      metadata["Name"][0],
+			Version:   first(metadata["Version"]),
+			Ecosystem: PyPI,
+			Licenses:  pythonLicenses(metadata),
+			Path:      entry.Name(),
+		})
+	}
+
+	return components, nil
+}
+
+// readPythonMetadata reads the (possibly repeated) header fields of a core
+// metadata file
+func readPythonMetadata(path string) (map[string][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fields := map[string][]string{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		fields[key] = append(fields[key], strings.TrimSpace(value))
+	}
+
+	return fields, scanner.Err()
+}
+
+func pythonLicenses(metadata map[string][]string) []string {
+	if expression := first(metadata["License-Expression"]); expression != "" {
+		return []string{expression}
+	}
+
+	var licenses []string
+	for _, classifier := range metadata["Classifier"] {
+		if !strings.HasPrefix(classifier, "License ::") {
+			continue
+		}
+
+		parts := strings.Split(classifier, " :: ")
+		name := parts[len(parts)-1]
+		if name == "OSI Approved" {
+			continue
+		}
+		if id, ok := classifierLicenses[name]; ok {
+			name = id
+		}
+		licenses = append(licenses, name)
+	}
+	if len(licenses) > 0 {
+		return licenses
+	}
+
+	// The License field is free text and sometimes holds the whole license
+	if license := first(metadata["License"]); license != "" && license != "UNKNOWN" && len(license) < 64 && !strings.Contains(license, "\n") {
+		return []string{NormalizeLicense(license)}
+	}
+
+	return nil
+}
+
+func scanNodeModules(dir string) ([]Component, error) {
+	var components []Component
+	seen := map[string]bool{}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != "package.json" {
+			return nil
+		}
+
+		packageDir := filepath.Dir(path)
+		parent := filepath.Dir(packageDir)
+		if strings.HasPrefix(filepath.Base(parent), "@") {
+			parent = filepath.Dir(parent)
+		}
+		if filepath.Base(parent) != "node_modules" {
+			return nil
+		}
+
+		component, ok := readNodePackage(path)
+		if !ok {
+			return nil
+		}
+
+		key := component.Name + "@" + component.Version
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+
+		relPath, err := filepath.Rel(dir, packageDir)
+		if err != nil {
+			return err
+		}
+		component.Path = relPath
+
+		components = append(components, component)
+		return nil
+	})
+
+	return components, err
+}
+
+func readNodePackage(path string) (Component, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Component{}, false
+	}
+
+	var manifest struct {
+		Name     string          `json:"name"`
+		Version  string          `json:"version"`
+		License  json.RawMessage `json:"license"`
+		Licenses json.RawMessage `json:"licenses"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.Name == "" {
+		return Component{}, false
+	}
+
+	return Component{
+		Name:      manifest.Name,
+		Version:   manifest.Version,
+		Ecosystem: Npm,
+		Licenses:  nodeLicenses(manifest.License, manifest.Licenses),
+	}, true
+}
+
+// nodeLicenses reads the license field, which is an SPDX expression, or the
+// deprecated {"type": ...} object and licenses array
+func nodeLicenses(license json.RawMessage, licenses json.RawMessage) []string {
+	var expression string
+	if json.Unmarshal(license, &expression) == nil && expression != "" {
+		return []string{NormalizeLicense(expression)}
+	}
+
+	var object struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(license, &object) == nil && object.Type != "" {
+		return []string{NormalizeLicense(object.Type)}
+	}
+
+	var objects []struct {
+		Type string `json:"type"`
+	}
+	var result []string
+	if json.Unmarshal(licenses, &objects) == nil {
+		for _, o := range objects {
+			if o.Type != "" {
+				result = append(result, NormalizeLicense(o.Type))
+			}
+		}
+	}
+
+	return result
+}
+
+func scanDeclared(dir string) ([]Component, error) {
+	var components []Component
+
+	if data, err := os.ReadFile(filepath.Join(dir, "package-lock.json")); err == nil {
+		var lock struct {
+			Packages map[string]struct {
+				Version string `json:"version"`
+				License string `json:"license"`
+				Dev     bool   `json:"dev"`
+			} `json:"packages"`
+		}
+		if err := json.Unmarshal(data, &lock); err == nil && len(lock.Packages) > 0 {
+			for path, pkg := range lock.Packages {
+				i := strings.LastIndex(path, "node_modules/")
+				if i < 0 || pkg.Dev {
+					continue
+				}
+
+				component := Component{
+					Name:      path[i+len("node_modules/"):],
+					Version:   pkg.Version,
+					Ecosystem: Npm,
+					Path:      path,
+					Declared:  true,
+				}
+				if pkg.License != "" {
+					component.Licenses = []string{NormalizeLicense(pkg.License)}
+				}
+				components = append(components, component)
+			}
+		}
+	}
+
+	if len(components) == 0 {
+		if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+			var manifest struct {
+				Dependencies map[string]string `json:"dependencies"`
+			}
+			if err := json.Unmarshal(data, &manifest); err == nil {
+				for name, version := range manifest.Dependencies {
+					components = append(components, Component{Name: name, Version: version, Ecosystem: Npm, Path: "package.json", Declared: true})
+				}
+			}
+		}
+	}
+
+	if file, err := os.Open(filepath.Join(dir, "requirements.txt")); err == nil {
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			match := pinnedRequirement.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+			if match != nil {
+				components = append(components, Component{Name: match[1], Version: match[2], Ecosystem: PyPI, Path: "requirements.txt", Declared: true})
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return components, nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,77 @@
+package sbom
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []Component
+	}{
+		{
+			// Installed packages win over the requirements.txt next to them
+			fixture: "installed",
+			want: []Component{
+				{Name: "@types/node", Version: "22.7.5", Ecosystem: Npm, Licenses: []string{"MIT", "Apache-2.0"}, Path: filepath.Join("node_modules", "@types", "node")},
+				{Name: "debug", Version: "2.6.9", Ecosystem: Npm, Licenses: []string{"MIT"}, Path: filepath.Join("node_modules", "express", "node_modules", "debug")},
+				{Name: "debug", Version: "4.3.7", Ecosystem: Npm, Licenses: []string{"MIT"}, Path: filepath.Join("node_modules", "debug")},
+				{Name: "express", Version: "4.21.1", Ecosystem: Npm, Licenses: []string{"MIT"}, Path: filepath.Join("node_modules", "express")},
+				{Name: "requests", Version: "2.32.3", Ecosystem: PyPI, Licenses: []string{"Apache-2.0"}, Path: "requests-2.32.3.dist-info"},
+				{Name: "six", Version: "1.16.0", Ecosystem: PyPI, Licenses: []string{"MIT"}, Path: "six-1.16.0.dist-info"},
+			},
+		},
+		{
+			// package-lock.json wins over package.json, dev packages are left out
+			fixture: "lockfile",
+			want: []Component{
+				{Name: "@aws-sdk/client-s3", Version: "3.670.0", Ecosystem: Npm, Licenses: []string{"Apache-2.0"}, Path: "node_modules/@aws-sdk/client-s3", Declared: true},
+				{Name: "debug", Version: "2.6.9", Ecosystem: Npm, Licenses: []string{"MIT"}, Path: "node_modules/express/node_modules/debug", Declared: true},
+				{Name: "express", Version: "4.21.1", Ecosystem: Npm, Licenses: []string{"MIT"}, Path: "node_modules/express", Declared: true},
+				{Name: "left-pad", Version: "1.3.0", Ecosystem: Npm, Path: "node_modules/left-pad", Declared: true},
+				{Name: "Flask", Version: "3.0.3", Ecosystem: PyPI, Path: "requirements.txt", Declared: true},
+				{Name: "gunicorn", Version: "23.0.0", Ecosystem: PyPI, Path: "requirements.txt", Declared: true},
+			},
+		},
+		{
+			fixture: "manifest",
+			want: []Component{
+				{Name: "@hono/node-server", Version: "1.13.2", Ecosystem: Npm, Path: "package.json", Declared: true},
+				{Name: "express", Version: "^4.21.1", Ecosystem: Npm, Path: "package.json", Declared: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			components, err := Scan(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(components, tt.want) {
+				t.Errorf("Scan() =\n%+v\nwant\n%+v", components, tt.want)
+			}
+		})
+	}
+}
+
+func TestPURL(t *testing.T) {
+	tests := []struct {
+		component Component
+		want      string
+	}{
+		{component: Component{Name: "express", Version: "4.21.1", Ecosystem: Npm}, want: "pkg:npm/express@4.21.1"},
+		{component: Component{Name: "@types/node", Version: "22.7.5", Ecosystem: Npm}, want: "pkg:npm/%40types/node@22.7.5"},
+		{component: Component{Name: "express", Version: "^4.21.1", Ecosystem: Npm}, want: "pkg:npm/express"},
+		{component: Component{Name: "Typing_Extensions", Version: "4.12.2", Ecosystem: PyPI}, want: "pkg:pypi/typing-extensions@4.12.2"},
+		{component: Component{Name: "zope.interface", Ecosystem: PyPI}, want: "pkg:pypi/zope-interface"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.component.PURL(); got != tt.want {
+			t.Errorf("PURL() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package sbom
+
+import (
+	"strings"
+)
+
+// spdxIDs are the SPDX license identifiers commonly found in Python and npm
+// package metadata, keyed by their lowercase form
+var spdxIDs = map[string]string{}
+
+func init() {
+	for _, id := range []string{
+		"0BSD", "AFL-3.0", "AGPL-3.0-only", "AGPL-3.0-or-later", "Apache-1.1", "Apache-2.0",
+		"Artistic-2.0", "BlueOak-1.0.0", "BSD-1-Clause", "BSD-2-Clause", "BSD-3-Clause",
+		"BSD-3-Clause-Clear", "BSL-1.0", "CC-BY-3.0", "CC-BY-4.0", "CC-BY-SA-4.0", "CC0-1.0",
+		"CDDL-1.0", "EPL-1.0", "EPL-2.0", "EUPL-1.2", "GPL-2.0-only", "GPL-2.0-or-later",
+		"GPL-3.0-only", "GPL-3.0-or-later", "HPND", "ISC", "LGPL-2.0-only", "LGPL-2.0-or-later",
+		"LGPL-2.1-only", "LGPL-2.1-or-later", "LGPL-3.0-only", "LGPL-3.0-or-later", "MIT",
+		"MIT-0", "MPL-1.1", "MPL-2.0", "MS-PL", "OFL-1.1", "PostgreSQL", "PSF-2.0",
+		"Python-2.0", "SSPL-1.0", "Unicode-DFS-2016", "Unlicense", "UPL-1.0", "WTFPL", "X11",
+		"Zlib", "ZPL-2.1",
+	} {
+		spdxIDs[strings.ToLower(id)] = id
+	}
+
+	// Deprecated identifiers still widely used in package.json files
+	for alias, id := range map[string]string{
+		"gpl-2.0": "GPL-2.0-only", "gpl-2.0+": "GPL-2.0-or-later",
+		"gpl-3.0": "GPL-3.0-only", "gpl-3.0+": "GPL-3.0-or-later",
+		"lgpl-2.0": "LGPL-2.0-only", "lgpl-2.0+": "LGPL-2.0-or-later",
+		"lgpl-2.1": "LGPL-2.1-only", "lgpl-2.1+": "LGPL-2.1-or-later",
+		"lgpl-3.0": "LGPL-3.0-only", "lgpl-3.0+": "LGPL-3.0-or-later",
+		"agpl-3.0":   "AGPL-3.0-only",
+		"apache 2.0": "Apache-2.0", "apache-2": "Apache-2.0", "apache 2": "Apache-2.0",
+		"apache license 2.0": "Apache-2.0", "apache software license": "Apache-2.0",
+		"mit license": "MIT", "bsd": "BSD-3-Clause", "bsd license": "BSD-3-Clause",
+		"new bsd": "BSD-3-Clause", "new bsd license": "BSD-3-Clause", "simplified bsd": "BSD-2-Clause",
+		"isc license": "ISC", "mpl 2.0": "MPL-2.0", "psf": "PSF-2.0", "public domain": "Unlicense",
+	} {
+		spdxIDs[alias] = id
+	}
+}
+
+// classifierLicenses maps trove classifier license names to SPDX identifiers
+var classifierLicenses = map[string]string{
+	"MIT License":                                             "MIT",
+	"MIT No Attribution License (MIT-0)":                      "MIT-0",
+	"Apache Software License":                                 "Apache-2.0",
+	"BSD License":                                             "BSD-3-Clause",
+	"ISC License (ISCL)":                                      "ISC",
+	"Mozilla Public License 2.0 (MPL 2.0)":                    "MPL-2.0",
+	"Python Software Foundation License":                      "PSF-2.0",
+	"GNU General Public License v2 (GPLv2)":                   "GPL-2.0-only",
+	"GNU General Public License v2 or later (GPLv2+)":         "GPL-2.0-or-later",
+	"GNU General Public License v3 (GPLv3)":                   "GPL-3.0-only",
+	"GNU General Public License v3 or later (GPLv3+)":         "GPL-3.0-or-later",
+	"GNU Lesser General Public License v2 (LGPLv2)":           "LGPL-2.0-only",
+	"GNU Lesser General Public License v2 or later (LGPLv2+)": "LGPL-2.0-or-later",
+	"GNU Lesser General Public License v3 (LGPLv3)":           "LGPL-3.0-only",
+	"GNU Lesser General Public License v3 or later (LGPLv3+)": "LGPL-3.0-or-later",
+	"GNU Affero General Public License v3":                    "AGPL-3.0-only",
+	"GNU Affero General Public License v3 or later (AGPLv3+)": "AGPL-3.0-or-later",
+	"Eclipse Public License 2.0 (EPL-2.0)":                    "EPL-2.0",
+	"The Unlicense (Unlicense)":                               "Unlicense",
+	"Zope Public License":                                     "ZPL-2.1",
+	"CC0 1.0 Universal (CC0 1.0) Public Domain Dedication":    "CC0-1.0",
+	"Public Domain":                                           "Unlicense",
+}
+
+// NormalizeLicense maps a license name or identifier to its SPDX identifier
+// when it is a known one, and returns it unchanged otherwise. Expressions
+// like "(MIT OR Apache-2.0)" have each identifier normalized
+func NormalizeLicense(license string) string {
+	license = strings.TrimSpace(license)
+	if id, ok := spdxIDs[strings.ToLower(license)]; ok {
+		return id
+	}
+
+	if !IsExpression(license) {
+		return license
+	}
+
+	fields := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(license))
+	for i, field := range fields {
+		if id, ok := spdxIDs[strings.ToLower(field)]; ok {
+			fields[i] = id
+		}
+	}
+
+	return strings.NewReplacer("( ", "(", " )", ")").Replace(strings.Join(fields, " "))
+}
+
+// IsSPDXID reports whether a license is a known SPDX license identifier
+func IsSPDXID(license string) bool {
+	id, ok := spdxIDs[strings.ToLower(license)]
+	return ok && id == license
+}
+
+// IsExpression reports whether a license is an SPDX expression combining
+// identifiers with AND, OR or WITH
+func IsExpression(license string) bool {
+	for _, operator := range []string{" OR ", " AND ", " WITH "} {
+		if strings.Contains(license, operator) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpressionIDs returns the license identifiers in an SPDX expression,
+// leaving out the exceptions that follow WITH
+func ExpressionIDs(expression string) []string {
+	var ids []string
+	fields := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(expression))
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "OR", "AND":
+			continue
+		case "WITH":
+			i++
+			continue
+		}
+		ids = append(ids, fields[i])
+	}
+	return ids
+}
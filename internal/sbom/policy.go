@@ -0,0 +1,201 @@
+package sbom
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"text/tabwriter"
+)
+
+// Policy decides which licenses may be shipped. A license is rejected if it
+// matches Deny, or if Allow is set and it doesn't match Allow. Patterns are
+// SPDX identifiers and may use globs, e.g. GPL-*
+type Policy struct {
+	Allow      []string
+	Deny       []string
+	Exceptions []Exception
+}
+
+// Exception approves a package regardless of its license, optionally only for
+// one version
+type Exception struct {
+	Package string
+	Version string
+	Reason  string
+}
+
+// Violation is a component whose license the policy rejects
+type Violation struct {
+	Component Component
+	Reason    string
+}
+
+func (p Policy) Empty() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0
+}
+
+// Check returns the components whose licenses the policy rejects
+func (p Policy) Check(components []Component) []Violation {
+	var violations []Violation
+	for _, component := range components {
+		if p.excepted(component) {
+			continue
+		}
+
+		if reason := p.reject(component.Licenses); reason != "" {
+			violations = append(violations, Violation{Component: component, Reason: reason})
+		}
+	}
+
+	return violations
+}
+
+func (p Policy) excepted(component Component) bool {
+	for _, exception := range p.Exceptions {
+		if exception.Package == component.Name && (exception.Version == "" || exception.Version == component.Version) {
+			return true
+		}
+	}
+	return false
+}
+
+// reject returns why a component's licenses aren't acceptable, or an empty
+// string if they are. Multiple licenses (e.g. several license classifiers)
+// mean the package is offered under any of them
+func (p Policy) reject(licenses []string) string {
+	if len(licenses) == 0 {
+		if len(p.Allow) > 0 {
+			return "unknown license"
+		}
+		return ""
+	}
+
+	var reasons []string
+	for _, license := range licenses {
+		reason := p.rejectExpression(license)
+		if reason == "" {
+			return ""
+		}
+		reasons = append(reasons, reason)
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+// rejectExpression evaluates an SPDX expression, where OR needs one acceptable
+// side and AND needs both
+func (p Policy) rejectExpression(expression string) string {
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expression))
+	parser := &expressionParser{tokens: tokens, policy: p}
+	reason := parser.parseOr()
+	if parser.pos != len(tokens) {
+		// Not a valid expression, judge it as a single license name
+		return p.rejectID(expression)
+	}
+	return reason
+}
+
+func (p Policy) rejectID(id string) string {
+	// SPDX documents say NOASSERTION when the license wasn't determined
+	if strings.EqualFold(id, "NOASSERTION") {
+		return p.reject(nil)
+	}
+
+	for _, pattern := range p.Deny {
+		if matchLicense(pattern, id) {
+			return id + " is denied"
+		}
+	}
+
+	if len(p.Allow) == 0 {
+		return ""
+	}
+
+	for _, pattern := range p.Allow {
+		if matchLicense(pattern, id) {
+			return ""
+		}
+	}
+
+	return id + " is not allowed"
+}
+
+func matchLicense(pattern string, id string) bool {
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(id))
+	return err == nil && matched
+}
+
+type expressionParser struct {
+	tokens []string
+	pos    int
+	policy Policy
+}
+
+func (e *expressionParser) peek() string {
+	if e.pos < len(e.tokens) {
+		return e.tokens[e.pos]
+	}
+	return ""
+}
+
+func (e *expressionParser) parseOr() string {
+	reason := e.parseAnd()
+	for e.peek() == "OR" {
+		e.pos++
+		other := e.parseAnd()
+		if reason == "" || other == "" {
+			reason = ""
+		} else {
+			reason = reason + " and " + other
+		}
+	}
+	return reason
+}
+
+func (e *expressionParser) parseAnd() string {
+	reason := e.parseTerm()
+	for e.peek() == "AND" {
+		e.pos++
+		other := e.parseTerm()
+		if reason == "" {
+			reason = other
+		} else if other != "" {
+			reason = reason + ", " + other
+		}
+	}
+	return reason
+}
+
+func (e *expressionParser) parseTerm() string {
+	token := e.peek()
+	if token == "(" {
+		e.pos++
+		reason := e.parseOr()
+		if e.peek() == ")" {
+			e.pos++
+		}
+		return reason
+	}
+
+	e.pos++
+	// Exceptions like Classpath-exception-2.0 only relax the license
+	if e.peek() == "WITH" {
+		e.pos += 2
+	}
+	return e.policy.rejectID(token)
+}
+
+// PrintViolations writes a table of the packages rejected by the policy
+func PrintViolations(w io.Writer, violations []Violation) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PACKAGE\tVERSION\tLICENSE\tREASON")
+	for _, violation := range violations {
+		license := strings.Join(violation.Component.Licenses, ", ")
+		if license == "" {
+			license = "-"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", violation.Component.Name, violation.Component.Version, license, violation.Reason)
+	}
+	table.Flush()
+}
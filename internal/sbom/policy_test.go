@@ -0,0 +1,75 @@
+package sbom
+
+import "testing"
+
+func TestPolicyReject(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		licenses []string
+		want     string
+	}{
+		{name: "no policy", policy: Policy{}, licenses: []string{"GPL-3.0-only"}, want: ""},
+		{name: "allowed", policy: Policy{Allow: []string{"MIT"}}, licenses: []string{"MIT"}, want: ""},
+		{name: "case insensitive", policy: Policy{Allow: []string{"mit"}}, licenses: []string{"MIT"}, want: ""},
+		{name: "not allowed", policy: Policy{Allow: []string{"MIT"}}, licenses: []string{"ISC"}, want: "ISC is not allowed"},
+		{name: "denied", policy: Policy{Deny: []string{"GPL-*"}}, licenses: []string{"GPL-3.0-only"}, want: "GPL-3.0-only is denied"},
+		{name: "deny wins over allow", policy: Policy{Allow: []string{"*"}, Deny: []string{"AGPL-*"}}, licenses: []string{"AGPL-3.0-only"}, want: "AGPL-3.0-only is denied"},
+		{name: "any of several licenses", policy: Policy{Allow: []string{"MIT"}}, licenses: []string{"GPL-2.0-only", "MIT"}, want: ""},
+		{name: "none of several licenses", policy: Policy{Allow: []string{"MIT"}}, licenses: []string{"GPL-2.0-only", "ISC"}, want: "GPL-2.0-only is not allowed; ISC is not allowed"},
+		{name: "unknown with allow", policy: Policy{Allow: []string{"MIT"}}, licenses: nil, want: "unknown license"},
+		{name: "unknown with deny", policy: Policy{Deny: []string{"GPL-*"}}, licenses: nil, want: ""},
+		{name: "noassertion with allow", policy: Policy{Allow: []string{"MIT"}}, licenses: []string{"NOASSERTION"}, want: "unknown license"},
+		{name: "noassertion with deny", policy: Policy{Deny: []string{"GPL-*"}}, licenses: []string{"NOASSERTION"}, want: ""},
+		{name: "or one side allowed", policy: Policy{Allow: []string{"MIT"}}, licenses: []string{"GPL-3.0-only OR MIT"}, want: ""},
+		{name: "or neither side allowed", policy: Policy{Allow: []string{"MIT"}}, licenses: []string{"GPL-3.0-only OR ISC"}, want: "GPL-3.0-only is not allowed and ISC is not allowed"},
+		{name: "or one side denied", policy: Policy{Deny: []string{"GPL-*"}}, licenses: []string{"GPL-3.0-only OR MIT"}, want: ""},
+		{name: "and both allowed", policy: Policy{Allow: []string{"MIT", "ISC"}}, licenses: []string{"MIT AND ISC"}, want: ""},
+		{name: "and one side denied", policy: Policy{Deny: []string{"GPL-*"}}, licenses: []string{"MIT AND GPL-3.0-only"}, want: "GPL-3.0-only is denied"},
+		{name: "and both rejected", policy: Policy{Allow: []string{"Apache-2.0"}}, licenses: []string{"MIT AND ISC"}, want: "MIT is not allowed, ISC is not allowed"},
+		{name: "and binds tighter than or", policy: Policy{Deny: []string{"GPL-*"}}, licenses: []string{"MIT OR GPL-3.0-only AND ISC"}, want: ""},
+		{name: "and binds tighter than or, rejected", policy: Policy{Deny: []string{"GPL-*", "ISC"}}, licenses: []string{"GPL-3.0-only OR ISC AND MIT"}, want: "GPL-3.0-only is denied and ISC is denied"},
+		{name: "parentheses", policy: Policy{Deny: []string{"GPL-*"}}, licenses: []string{"(MIT OR GPL-3.0-only) AND ISC"}, want: ""},
+		{name: "parentheses rejected", policy: Policy{Deny: []string{"GPL-*"}}, licenses: []string{"MIT AND (GPL-2.0-only OR GPL-3.0-only)"}, want: "GPL-2.0-only is denied and GPL-3.0-only is denied"},
+		{name: "nested parentheses", policy: Policy{Allow: []string{"MIT", "ISC"}}, licenses: []string{"((MIT OR BSD-3-Clause) AND (ISC))"}, want: ""},
+		{name: "with exception", policy: Policy{Allow: []string{"GPL-2.0-only"}}, licenses: []string{"GPL-2.0-only WITH Classpath-exception-2.0"}, want: ""},
+		{name: "with exception denied", policy: Policy{Deny: []string{"GPL-*"}}, licenses: []string{"GPL-2.0-only WITH Classpath-exception-2.0"}, want: "GPL-2.0-only is denied"},
+		{name: "with exception in or", policy: Policy{Deny: []string{"GPL-*"}}, licenses: []string{"GPL-2.0-only WITH Classpath-exception-2.0 OR MIT"}, want: ""},
+		{name: "not an expression", policy: Policy{Allow: []string{"MIT"}}, licenses: []string{"SEE LICENSE IN LICENSE.md"}, want: "SEE LICENSE IN LICENSE.md is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.reject(tt.licenses); got != tt.want {
+				t.Errorf("reject(%q) = %q, want %q", tt.licenses, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{
+		Deny: []string{"GPL-*"},
+		Exceptions: []Exception{
+			{Package: "readline", Reason: "only used in development"},
+			{Package: "left-pad", Version: "1.0.0", Reason: "reviewed"},
+		},
+	}
+	components := []Component{
+		{Name: "express", Version: "4.21.1", Licenses: []string{"MIT"}},
+		{Name: "readline", Version: "1.3.0", Licenses: []string{"GPL-3.0-only"}},
+		{Name: "left-pad", Version: "1.0.0", Licenses: []string{"GPL-3.0-only"}},
+		{Name: "left-pad", Version: "1.1.0", Licenses: []string{"GPL-3.0-only"}},
+	}
+
+	violations := policy.Check(components)
+	if len(violations) != 1 {
+		t.Fatalf("Check returned %d violations, want 1: %v", len(violations), violations)
+	}
+	if violations[0].Component.Name != "left-pad" || violations[0].Component.Version != "1.1.0" {
+		t.Errorf("violation for %s@%s, want left-pad@1.1.0", violations[0].Component.Name, violations[0].Component.Version)
+	}
+	if violations[0].Reason != "GPL-3.0-only is denied" {
+		t.Errorf("reason = %q, want %q", violations[0].Reason, "GPL-3.0-only is denied")
+	}
+}
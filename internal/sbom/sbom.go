@@ -0,0 +1,237 @@
+package sbom
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	CycloneDX Format = "cyclonedx"
+	SPDX      Format = "spdx"
+)
+
+// Document describes the artifact an SBOM is generated for
+type Document struct {
+	Name         string
+	Platform     string
+	SHA256       string
+	UpifyVersion string
+	Components   []Component
+}
+
+// Path returns the SBOM location for an artifact, e.g. dist/app.zip ->
+// dist/app.cdx.json
+func Path(zipPath string, format Format) string {
+	return FileName(strings.TrimSuffix(zipPath, ".zip"), format)
+}
+
+// FileName adds the extension for the format to base, e.g. sbom.cdx.json
+func FileName(base string, format Format) string {
+	if format == SPDX {
+		return base + ".spdx.json"
+	}
+	return base + ".cdx.json"
+}
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(value)) {
+	case "", CycloneDX:
+		return CycloneDX, nil
+	case SPDX:
+		return SPDX, nil
+	default:
+		return "", fmt.Errorf("unsupported SBOM format: %s (use cyclonedx or spdx)", value)
+	}
+}
+
+func Write(doc Document, format Format, path string) error {
+	var content interface{}
+	switch format {
+	case CycloneDX:
+		content = cycloneDX(doc)
+	case SPDX:
+		content = spdx(doc)
+	default:
+		return fmt.Errorf("unsupported SBOM format: %s", format)
+	}
+
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Writing %s (%d components)...\n", path, len(doc.Components))
+	return os.WriteFile(path, data, 0644)
+}
+
+func cycloneDX(doc Document) map[string]interface{} {
+	components := make([]map[string]interface{}, 0, len(doc.Components))
+	for _, c := range doc.Components {
+		component := map[string]interface{}{
+			"type":    "library",
+			"bom-ref": c.PURL() + "#" + c.Path,
+			"name":    c.Name,
+			"purl":    c.PURL(),
+		}
+		if c.Version != "" {
+			component["version"] = c.Version
+		}
+		if licenses := cycloneDXLicenses(c.Licenses); len(licenses) > 0 {
+			component["licenses"] = licenses
+		}
+		components = append(components, component)
+	}
+
+	application := map[string]interface{}{
+		"type":    "application",
+		"bom-ref": doc.Name,
+		"name":    doc.Name,
+	}
+	if doc.SHA256 != "" {
+		application["hashes"] = []map[string]string{{"alg": "SHA-256", "content": doc.SHA256}}
+	}
+
+	tool := map[string]interface{}{"type": "application", "name": "upify"}
+	if doc.UpifyVersion != "" {
+		tool["version"] = doc.UpifyVersion
+	}
+
+	return map[string]interface{}{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + newUUID(),
+		"version":      1,
+		"metadata": map[string]interface{}{
+			"timestamp":  time.Now().UTC().Format(time.RFC3339),
+			"tools":      map[string]interface{}{"components": []interface{}{tool}},
+			"component":  application,
+			"properties": []map[string]string{{"name": "upify:platform", "value": doc.Platform}},
+		},
+		"components": components,
+	}
+}
+
+func cycloneDXLicenses(licenses []string) []map[string]interface{} {
+	if len(licenses) == 1 && IsExpression(licenses[0]) {
+		return []map[string]interface{}{{"expression": licenses[0]}}
+	}
+
+	var result []map[string]interface{}
+	for _, license := range licenses {
+		if IsSPDXID(license) {
+			result = append(result, map[string]interface{}{"license": map[string]string{"id": license}})
+		} else {
+			result = append(result, map[string]interface{}{"license": map[string]string{"name": license}})
+		}
+	}
+	return result
+}
+
+func spdx(doc Document) map[string]interface{} {
+	packages := []map[string]interface{}{}
+	relationships := []map[string]string{{
+		"spdxElementId":      "SPDXRef-DOCUMENT",
+		"relationshipType":   "DESCRIBES",
+		"relatedSpdxElement": "SPDXRef-Artifact",
+	}}
+
+	artifact := map[string]interface{}{
+		"SPDXID":           "SPDXRef-Artifact",
+		"name":             doc.Name,
+		"downloadLocation": "NOASSERTION",
+		"filesAnalyzed":    false,
+		"licenseConcluded": "NOASSERTION",
+		"licenseDeclared":  "NOASSERTION",
+		"copyrightText":    "NOASSERTION",
+	}
+	if doc.SHA256 != "" {
+		artifact["checksums"] = []map[string]string{{"algorithm": "SHA256", "checksumValue": doc.SHA256}}
+	}
+	packages = append(packages, artifact)
+
+	for i, c := range doc.Components {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		pkg := map[string]interface{}{
+			"SPDXID":           id,
+			"name":             c.Name,
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed":    false,
+			"licenseConcluded": "NOASSERTION",
+			"licenseDeclared":  spdxLicense(c.Licenses),
+			"copyrightText":    "NOASSERTION",
+			"externalRefs": []map[string]string{{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  c.PURL(),
+			}},
+		}
+		if c.Version != "" {
+			pkg["versionInfo"] = c.Version
+		}
+		packages = append(packages, pkg)
+
+		relationships = append(relationships, map[string]string{
+			"spdxElementId":      "SPDXRef-Artifact",
+			"relationshipType":   "CONTAINS",
+			"relatedSpdxElement": id,
+		})
+	}
+
+	creators := []string{"Tool: upify"}
+	if doc.UpifyVersion != "" {
+		creators = []string{"Tool: upify-" + doc.UpifyVersion}
+	}
+
+	return map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              doc.Name + "-" + doc.Platform,
+		"documentNamespace": "https://spdx.org/spdxdocs/" + doc.Name + "-" + newUUID(),
+		"creationInfo": map[string]interface{}{
+			"created":  time.Now().UTC().Format(time.RFC3339),
+			"creators": creators,
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
+
+// spdxLicense combines a component's licenses into an SPDX expression, or
+// NOASSERTION if any of them isn't a known SPDX identifier
+func spdxLicense(licenses []string) string {
+	if len(licenses) == 0 {
+		return "NOASSERTION"
+	}
+
+	for _, license := range licenses {
+		ids := []string{license}
+		if IsExpression(license) {
+			ids = ExpressionIDs(license)
+		}
+		for _, id := range ids {
+			if !IsSPDXID(id) {
+				return "NOASSERTION"
+			}
+		}
+	}
+
+	if len(licenses) == 1 {
+		return licenses[0]
+	}
+	return "(" + strings.Join(licenses, " AND ") + ")"
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,219 @@
+package sbom
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testDocument = Document{
+	Name:         "app",
+	Platform:     "aws-lambda",
+	SHA256:       "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	UpifyVersion: "1.2.0",
+	Components: []Component{
+		{Name: "express", Version: "4.21.1", Ecosystem: Npm, Licenses: []string{"MIT"}, Path: "node_modules/express"},
+		{Name: "dual", Version: "1.0.0", Ecosystem: Npm, Licenses: []string{"MIT OR Apache-2.0"}, Path: "node_modules/dual"},
+		{Name: "six", Version: "1.16.0", Ecosystem: PyPI, Licenses: []string{"MIT", "Custom License"}, Path: "six-1.16.0.dist-info"},
+		{Name: "left-pad", Ecosystem: Npm, Path: "package.json", Declared: true},
+	},
+}
+
+func writeDocument(t *testing.T, format Format, v interface{}) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName("app", format))
+	if err := Write(testDocument, format, path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("failed to parse %s: %v", path, err)
+	}
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	var bom struct {
+		BOMFormat   string `json:"bomFormat"`
+		SpecVersion string `json:"specVersion"`
+		Metadata    struct {
+			Component struct {
+				Name   string `json:"name"`
+				Hashes []struct {
+					Alg     string `json:"alg"`
+					Content string `json:"content"`
+				} `json:"hashes"`
+			} `json:"component"`
+			Properties []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"properties"`
+		} `json:"metadata"`
+		Components []struct {
+			BOMRef   string                   `json:"bom-ref"`
+			Name     string                   `json:"name"`
+			Version  string                   `json:"version"`
+			PURL     string                   `json:"purl"`
+			Licenses []map[string]interface{} `json:"licenses"`
+		} `json:"components"`
+	}
+	writeDocument(t, CycloneDX, &bom)
+
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" {
+		t.Errorf("bomFormat %q specVersion %q, want CycloneDX 1.5", bom.BOMFormat, bom.SpecVersion)
+	}
+	if bom.Metadata.Component.Name != "app" || len(bom.Metadata.Component.Hashes) != 1 || bom.Metadata.Component.Hashes[0].Content != testDocument.SHA256 {
+		t.Errorf("metadata component = %+v, want app with its SHA-256", bom.Metadata.Component)
+	}
+	if len(bom.Metadata.Properties) != 1 || bom.Metadata.Properties[0].Value != "aws-lambda" {
+		t.Errorf("metadata properties = %+v, want the platform", bom.Metadata.Properties)
+	}
+	if len(bom.Components) != len(testDocument.Components) {
+		t.Fatalf("%d components, want %d", len(bom.Components), len(testDocument.Components))
+	}
+
+	tests := []struct {
+		index    int
+		bomRef   string
+		purl     string
+		licenses []map[string]interface{}
+	}{
+		{
+			index:    0,
+			bomRef:   "pkg:npm/express@4.21.1#node_modules/express",
+			purl:     "pkg:npm/express@4.21.1",
+			licenses: []map[string]interface{}{{"license": map[string]interface{}{"id": "MIT"}}},
+		},
+		{
+			index:    1,
+			bomRef:   "pkg:npm/dual@1.0.0#node_modules/dual",
+			purl:     "pkg:npm/dual@1.0.0",
+			licenses: []map[string]interface{}{{"expression": "MIT OR Apache-2.0"}},
+		},
+		{
+			index:  2,
+			bomRef: "pkg:pypi/six@1.16.0#six-1.16.0.dist-info",
+			purl:   "pkg:pypi/six@1.16.0",
+			licenses: []map[string]interface{}{
+				{"license": map[string]interface{}{"id": "MIT"}},
+				{"license": map[string]interface{}{"name": "Custom License"}},
+			},
+		},
+		{
+			index:  3,
+			bomRef: "pkg:npm/left-pad#package.json",
+			purl:   "pkg:npm/left-pad",
+		},
+	}
+	for _, tt := range tests {
+		component := bom.Components[tt.index]
+		if component.BOMRef != tt.bomRef || component.PURL != tt.purl {
+			t.Errorf("component %d bom-ref %q purl %q, want %q and %q", tt.index, component.BOMRef, component.PURL, tt.bomRef, tt.purl)
+		}
+		if !reflect.DeepEqual(component.Licenses, tt.licenses) {
+			t.Errorf("component %d licenses = %v, want %v", tt.index, component.Licenses, tt.licenses)
+		}
+	}
+}
+
+func TestWriteSPDX(t *testing.T) {
+	var doc struct {
+		SPDXVersion  string `json:"spdxVersion"`
+		Name         string `json:"name"`
+		CreationInfo struct {
+			Creators []string `json:"creators"`
+		} `json:"creationInfo"`
+		Packages []struct {
+			SPDXID          string `json:"SPDXID"`
+			Name            string `json:"name"`
+			VersionInfo     string `json:"versionInfo"`
+			LicenseDeclared string `json:"licenseDeclared"`
+			Checksums       []struct {
+				Algorithm     string `json:"algorithm"`
+				ChecksumValue string `json:"checksumValue"`
+			} `json:"checksums"`
+			ExternalRefs []struct {
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+		Relationships []struct {
+			SPDXElementID      string `json:"spdxElementId"`
+			RelationshipType   string `json:"relationshipType"`
+			RelatedSPDXElement string `json:"relatedSpdxElement"`
+		} `json:"relationships"`
+	}
+	writeDocument(t, SPDX, &doc)
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.Name != "app-aws-lambda" {
+		t.Errorf("spdxVersion %q name %q, want SPDX-2.3 app-aws-lambda", doc.SPDXVersion, doc.Name)
+	}
+	if !reflect.DeepEqual(doc.CreationInfo.Creators, []string{"Tool: upify-1.2.0"}) {
+		t.Errorf("creators = %v, want Tool: upify-1.2.0", doc.CreationInfo.Creators)
+	}
+	if len(doc.Packages) != len(testDocument.Components)+1 {
+		t.Fatalf("%d packages, want the artifact and %d components", len(doc.Packages), len(testDocument.Components))
+	}
+
+	artifact := doc.Packages[0]
+	if artifact.SPDXID != "SPDXRef-Artifact" || len(artifact.Checksums) != 1 || artifact.Checksums[0].ChecksumValue != testDocument.SHA256 {
+		t.Errorf("artifact = %+v, want SPDXRef-Artifact with its SHA256", artifact)
+	}
+
+	tests := []struct {
+		id      string
+		version string
+		license string
+		purl    string
+	}{
+		{id: "SPDXRef-Package-1", version: "4.21.1", license: "MIT", purl: "pkg:npm/express@4.21.1"},
+		{id: "SPDXRef-Package-2", version: "1.0.0", license: "MIT OR Apache-2.0", purl: "pkg:npm/dual@1.0.0"},
+		// Custom License isn't an SPDX identifier
+		{id: "SPDXRef-Package-3", version: "1.16.0", license: "NOASSERTION", purl: "pkg:pypi/six@1.16.0"},
+		{id: "SPDXRef-Package-4", license: "NOASSERTION", purl: "pkg:npm/left-pad"},
+	}
+	for i, tt := range tests {
+		pkg := doc.Packages[i+1]
+		if pkg.SPDXID != tt.id || pkg.VersionInfo != tt.version || pkg.LicenseDeclared != tt.license {
+			t.Errorf("package %s = %+v, want version %q license %q", tt.id, pkg, tt.version, tt.license)
+		}
+		if len(pkg.ExternalRefs) != 1 || pkg.ExternalRefs[0].ReferenceLocator != tt.purl {
+			t.Errorf("package %s refs = %+v, want %q", tt.id, pkg.ExternalRefs, tt.purl)
+		}
+	}
+
+	if len(doc.Relationships) != len(testDocument.Components)+1 {
+		t.Fatalf("%d relationships, want %d", len(doc.Relationships), len(testDocument.Components)+1)
+	}
+	if r := doc.Relationships[0]; r.SPDXElementID != "SPDXRef-DOCUMENT" || r.RelationshipType != "DESCRIBES" || r.RelatedSPDXElement != "SPDXRef-Artifact" {
+		t.Errorf("first relationship = %+v, want the document describing the artifact", r)
+	}
+	for i, r := range doc.Relationships[1:] {
+		if r.SPDXElementID != "SPDXRef-Artifact" || r.RelationshipType != "CONTAINS" || r.RelatedSPDXElement != tests[i].id {
+			t.Errorf("relationship = %+v, want the artifact containing %s", r, tests[i].id)
+		}
+	}
+}
+
+func TestSPDXLicense(t *testing.T) {
+	tests := []struct {
+		licenses []string
+		want     string
+	}{
+		{licenses: nil, want: "NOASSERTION"},
+		{licenses: []string{"MIT"}, want: "MIT"},
+		{licenses: []string{"MIT", "Apache-2.0"}, want: "(MIT AND Apache-2.0)"},
+		{licenses: []string{"GPL-2.0-only WITH Classpath-exception-2.0"}, want: "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{licenses: []string{"MIT", "Proprietary"}, want: "NOASSERTION"},
+	}
+
+	for _, tt := range tests {
+		if got := spdxLicense(tt.licenses); got != tt.want {
+			t.Errorf("spdxLicense(%q) = %q, want %q", tt.licenses, got, tt.want)
+		}
+	}
+}